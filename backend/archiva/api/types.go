@@ -0,0 +1,67 @@
+// Package api has type definitions for archiva
+package api
+
+import "fmt"
+
+// Error is returned by the REST API when something goes wrong
+type Error struct {
+	StatusCode   int    `json:"-"`
+	ErrorKey     string `json:"errorKey"`
+	ErrorMessage string `json:"errorMessage"`
+	FieldName    string `json:"fieldName"`
+}
+
+// Error returns a string for the error and satisfies the error interface
+func (e *Error) Error() string {
+	out := fmt.Sprintf("archiva error %d", e.StatusCode)
+	if e.ErrorKey != "" {
+		out += ": " + e.ErrorKey
+	}
+	if e.ErrorMessage != "" {
+		out += ": " + e.ErrorMessage
+	}
+	return out
+}
+
+// Repository is a repository the user can browse
+type Repository struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// BrowseResultEntry is a group or a project in a BrowseResult
+//
+// Name is the full group ID of a group, or the group ID and artifact
+// ID joined with "." for a project.
+type BrowseResultEntry struct {
+	Name       string `json:"name"`
+	Project    bool   `json:"project"`
+	GroupID    string `json:"groupId"`
+	ArtifactID string `json:"artifactId"`
+}
+
+// BrowseResult is returned when browsing groups
+type BrowseResult struct {
+	BrowseResultEntries []BrowseResultEntry `json:"browseResultEntries"`
+}
+
+// VersionsList is the list of versions of a project
+type VersionsList struct {
+	Versions []string `json:"versions"`
+}
+
+// ArtifactDownloadInfo describes a file of a project version
+//
+// Size is formatted for people to read so it isn't used.
+type ArtifactDownloadInfo struct {
+	GroupID       string `json:"groupId"`
+	ArtifactID    string `json:"artifactId"`
+	Version       string `json:"version"`
+	Classifier    string `json:"classifier"`
+	FileExtension string `json:"fileExtension"`
+	Type          string `json:"type"`
+	Size          string `json:"size"`
+	Path          string `json:"path"`
+	FullPath      string `json:"fullPath"`
+	URL           string `json:"url"`
+}
@@ -0,0 +1,826 @@
+// Package archiva provides an interface to the Apache Archiva
+// repository manager.
+package archiva
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/archiva/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/artifact"
+	"github.com/rclone/rclone/lib/bucket"
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+const (
+	minSleep      = 10 * time.Millisecond
+	maxSleep      = 2 * time.Second
+	decayConstant = 2 // bigger for slower decay, exponential
+	browsePath    = "/restServices/archivaServices/browseService"
+	davPath       = "/repository"
+)
+
+// timeUnset is the modification time of files without a Last-Modified
+var timeUnset = time.Unix(0, 0)
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "archiva",
+		Description: "Apache Archiva",
+		NewFs:       NewFs,
+		Options: []fs.Option{{
+			Name:     "endpoint",
+			Help:     "URL of the Archiva server, including the context path if any.",
+			Required: true,
+			Examples: []fs.OptionExample{{
+				Value: "https://archiva.example.com",
+				Help:  "Archiva served from the root",
+			}, {
+				Value: "https://example.com/archiva",
+				Help:  "Archiva served with a context path",
+			}},
+		}, {
+			Name: "user",
+			Help: "User name.\n\nLeave blank for anonymous access.",
+		}, {
+			Name:       "pass",
+			Help:       "Password.",
+			IsPassword: true,
+		}, {
+			Name:     config.ConfigEncoding,
+			Help:     config.ConfigEncodingHelp,
+			Advanced: true,
+			Default: (encoder.Display |
+				encoder.EncodeBackSlash |
+				encoder.EncodeInvalidUtf8),
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	Endpoint string               `config:"endpoint"`
+	User     string               `config:"user"`
+	Pass     string               `config:"pass"`
+	Enc      encoder.MultiEncoder `config:"encoding"`
+}
+
+// Fs represents a remote archiva server
+type Fs struct {
+	name          string         // name of this remote
+	root          string         // the path we are working on if any
+	opt           Options        // parsed config options
+	features      *fs.Features   // optional features
+	srv           *rest.Client   // the connection to the server
+	pacer         *fs.Pacer      // pacer for API calls
+	ci            *fs.ConfigInfo // global config
+	rootBucket    string         // repository part of root (if any)
+	rootDirectory string         // directory part of root (if any)
+}
+
+// Object describes an archiva file
+type Object struct {
+	fs          *Fs       // what this object is part of
+	remote      string    // The remote path
+	hasMetaData bool      // whether the info below has been read
+	size        int64     // size of the object
+	modTime     time.Time // modification time on the server
+	sha1        string    // SHA-1 of the object if read
+	md5         string    // MD5 of the object if read
+	mimeType    string    // Content-Type of the object
+}
+
+// ------------------------------------------------------------
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	if f.rootBucket == "" {
+		return "Archiva root"
+	}
+	if f.rootDirectory == "" {
+		return fmt.Sprintf("Archiva repository %s", f.rootBucket)
+	}
+	return fmt.Sprintf("Archiva repository %s path %s", f.rootBucket, f.rootDirectory)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// parsePath parses a remote 'url'
+func parsePath(path string) (root string) {
+	root = strings.Trim(path, "/")
+	return
+}
+
+// split returns repository and repositoryPath from the
+// rootRelativePath relative to f.root
+func (f *Fs) split(rootRelativePath string) (repo, repoPath string) {
+	return bucket.Split(path.Join(f.root, rootRelativePath))
+}
+
+// split returns repository and repositoryPath from the object
+func (o *Object) split() (repo, repoPath string) {
+	return o.fs.split(o.remote)
+}
+
+// setRoot changes the root of the Fs
+func (f *Fs) setRoot(root string) {
+	f.root = parsePath(root)
+	f.rootBucket, f.rootDirectory = bucket.Split(f.root)
+}
+
+// itemPath returns the URL path of repoPath in repo in the
+// repository file server
+func (f *Fs) itemPath(repo, repoPath string) string {
+	return davPath + rest.URLPathEscape(path.Join("/", repo, f.opt.Enc.FromStandardPath(repoPath)))
+}
+
+// retryErrorCodes is a slice of error codes that we will retry
+var retryErrorCodes = []int{
+	429, // Too Many Requests.
+	500, // Internal Server Error
+	502, // Bad Gateway
+	503, // Service Unavailable
+	504, // Gateway Timeout
+	509, // Bandwidth Limit Exceeded
+}
+
+// shouldRetry returns a boolean as to whether this resp and err
+// deserve to be retried.  It returns the err as a convenience
+func shouldRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if fserrors.ContextError(ctx, &err) {
+		return false, err
+	}
+	return fserrors.ShouldRetry(err) || fserrors.ShouldRetryHTTP(resp, retryErrorCodes), err
+}
+
+// errorHandler parses a non 2xx error response into an error
+func errorHandler(resp *http.Response) error {
+	errResponse := new(api.Error)
+	err := rest.DecodeJSON(resp, &errResponse)
+	if err != nil {
+		fs.Debugf(nil, "Couldn't decode error response: %v", err)
+	}
+	errResponse.StatusCode = resp.StatusCode
+	if errResponse.ErrorKey == "" && errResponse.ErrorMessage == "" {
+		errResponse.ErrorMessage = resp.Status
+	}
+	return errResponse
+}
+
+// isNotFound returns true if resp indicates the item wasn't found
+func isNotFound(resp *http.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusNotFound
+}
+
+// NewFs constructs an Fs from the path, repository:path
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	// Parse config into Options struct
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Endpoint == "" {
+		return nil, errors.New("endpoint not set")
+	}
+	endpoint, err := url.Parse(opt.Endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't parse endpoint %q", opt.Endpoint)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, errors.Errorf("endpoint %q must start with http:// or https://", opt.Endpoint)
+	}
+	if opt.Pass != "" {
+		opt.Pass, err = obscure.Reveal(opt.Pass)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't decrypt password")
+		}
+	}
+
+	rootURL := strings.TrimRight(opt.Endpoint, "/")
+	f := &Fs{
+		name:  name,
+		opt:   *opt,
+		srv:   rest.NewClient(fshttp.NewClient(ctx)).SetRoot(rootURL),
+		pacer: fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
+		ci:    fs.GetConfig(ctx),
+	}
+	f.setRoot(root)
+	f.features = (&fs.Features{
+		ReadMimeType:      true,
+		BucketBased:       true,
+		BucketBasedRootOK: true,
+	}).Fill(ctx, f)
+	f.srv.SetErrorHandler(errorHandler)
+	// The REST API rejects requests without a Referer as a CSRF
+	// protection
+	f.srv.SetHeader("Referer", rootURL+"/")
+	if opt.User != "" {
+		f.srv.SetUserPass(opt.User, opt.Pass)
+	}
+
+	if f.rootBucket != "" && f.rootDirectory != "" {
+		// Check to see if the (repository,directory) is actually an existing file
+		oldRoot := f.root
+		newRoot, leaf := path.Split(oldRoot)
+		f.setRoot(newRoot)
+		_, err := f.NewObject(ctx, leaf)
+		if err != nil {
+			// File doesn't exist so return old f
+			f.setRoot(oldRoot)
+			return f, nil
+		}
+		// return an error with an fs which points to the parent
+		return f, fs.ErrorIsFile
+	}
+	return f, nil
+}
+
+// NewObject finds the Object at remote.  If it can't be found
+// it returns the error fs.ErrorObjectNotFound.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	o := &Object{
+		fs:     f,
+		remote: remote,
+	}
+	err := o.readMetaData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// callBrowse calls the browse service at apiPath for repo decoding
+// the result into result
+//
+// A missing item is returned as an empty result.
+func (f *Fs) callBrowse(ctx context.Context, apiPath, repo string, result interface{}) error {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   browsePath + apiPath,
+	}
+	if repo != "" {
+		opts.Parameters = url.Values{
+			"repositoryId": {repo},
+		}
+	}
+	var resp *http.Response
+	var err error
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, result)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil && isNotFound(resp) {
+		return nil
+	}
+	return err
+}
+
+// listRepositories lists the repositories as directories
+func (f *Fs) listRepositories(ctx context.Context) (entries fs.DirEntries, err error) {
+	var repos []api.Repository
+	err = f.callBrowse(ctx, "/userRepositories", "", &repos)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list repositories")
+	}
+	for _, repo := range repos {
+		entries = append(entries, fs.NewDir(f.opt.Enc.ToStandardName(repo.ID), time.Time{}))
+	}
+	return entries, nil
+}
+
+// escapeParts joins parts with sep, escaping each one for use in a
+// URL path
+func escapeParts(parts []string, sep string) string {
+	escaped := make([]string, len(parts))
+	for i, part := range parts {
+		escaped[i] = url.PathEscape(part)
+	}
+	return strings.Join(escaped, sep)
+}
+
+// listGroups returns the leaf names of the groups and projects in the
+// group made from parts
+//
+// The browse service collapses groups with a single child into one
+// entry so only the first part after the group is used.
+func (f *Fs) listGroups(ctx context.Context, repo string, parts []string) (leaves []string, err error) {
+	apiPath := "/rootGroups"
+	if len(parts) > 0 {
+		apiPath = "/browseGroupId/" + escapeParts(parts, ".")
+	}
+	var result api.BrowseResult
+	err = f.callBrowse(ctx, apiPath, repo, &result)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to browse groups")
+	}
+	prefix := strings.Join(parts, ".")
+	if prefix != "" {
+		prefix += "."
+	}
+	for _, entry := range result.BrowseResultEntries {
+		name := strings.TrimPrefix(entry.Name, prefix)
+		if i := strings.IndexRune(name, '.'); i >= 0 && !entry.Project {
+			name = name[:i]
+		}
+		if name != "" {
+			leaves = append(leaves, name)
+		}
+	}
+	return leaves, nil
+}
+
+// listVersions returns the versions of the project made from parts,
+// the last part being the artifact ID
+func (f *Fs) listVersions(ctx context.Context, repo string, parts []string) (versions []string, err error) {
+	n := len(parts)
+	var result api.VersionsList
+	err = f.callBrowse(ctx, "/versionsList/"+escapeParts(parts[:n-1], ".")+"/"+url.PathEscape(parts[n-1]), repo, &result)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list versions")
+	}
+	return result.Versions, nil
+}
+
+// listFiles returns the file names of the project version made from
+// parts, the last two parts being the artifact ID and version
+func (f *Fs) listFiles(ctx context.Context, repo string, parts []string) (files []string, err error) {
+	n := len(parts)
+	var result []api.ArtifactDownloadInfo
+	apiPath := "/artifactDownloadInfos/" + escapeParts(parts[:n-2], ".") + "/" +
+		url.PathEscape(parts[n-2]) + "/" + url.PathEscape(parts[n-1])
+	err = f.callBrowse(ctx, apiPath, repo, &result)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list files")
+	}
+	for _, info := range result {
+		filePath := info.FullPath
+		if filePath == "" {
+			filePath = info.Path
+		}
+		if filePath != "" {
+			files = append(files, path.Base(filePath))
+		}
+	}
+	return files, nil
+}
+
+// newObjects makes Objects for the files at remotes
+//
+// The browse service doesn't return usable sizes or modification
+// times so the files are read with HEAD requests, --checkers at once.
+// Files which have gone are left out and files whose metadata can't
+// be read are returned without it.
+func (f *Fs) newObjects(ctx context.Context, remotes []string) (objects []*Object, err error) {
+	var (
+		objectsMu sync.Mutex // to protect objects
+		wg        sync.WaitGroup
+		checkers  = f.ci.Checkers
+		in        = make(chan string, checkers)
+	)
+	for i := 0; i < checkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for remote := range in {
+				o := &Object{
+					fs:      f,
+					remote:  remote,
+					size:    -1,
+					modTime: timeUnset,
+				}
+				switch err := o.readMetaData(ctx); err {
+				case nil:
+				case fs.ErrorObjectNotFound:
+					continue
+				default:
+					fs.Logf(o, "Failed to read metadata: %v", err)
+				}
+				objectsMu.Lock()
+				objects = append(objects, o)
+				objectsMu.Unlock()
+			}
+		}()
+	}
+	for _, remote := range remotes {
+		in <- remote
+	}
+	close(in)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// List the objects and directories in dir into entries.  The
+// entries can be returned in any order but should be for a
+// complete directory.
+//
+// dir should be "" to list the root, and should not have
+// trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+//
+// The maven2 layout is rebuilt from the browse service so a directory
+// may hold groups, projects, versions and files at once.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	repo, directory := f.split(dir)
+	if repo == "" {
+		if directory != "" {
+			return nil, fs.ErrorListBucketRequired
+		}
+		return f.listRepositories(ctx)
+	}
+	repo = f.opt.Enc.FromStandardName(repo)
+	var parts []string
+	if directory != "" {
+		parts = strings.Split(f.opt.Enc.FromStandardPath(directory), "/")
+	}
+	seen := map[string]struct{}{}
+	addDir := func(leaf string) {
+		if _, ok := seen[leaf]; ok {
+			return
+		}
+		seen[leaf] = struct{}{}
+		entries = append(entries, fs.NewDir(path.Join(dir, f.opt.Enc.ToStandardName(leaf)), time.Time{}))
+	}
+	groups, err := f.listGroups(ctx, repo, parts)
+	if err != nil {
+		return nil, err
+	}
+	for _, leaf := range groups {
+		addDir(leaf)
+	}
+	if len(parts) >= 2 {
+		versions, err := f.listVersions(ctx, repo, parts)
+		if err != nil {
+			return nil, err
+		}
+		for _, leaf := range versions {
+			addDir(leaf)
+		}
+	}
+	if len(parts) >= 3 {
+		files, err := f.listFiles(ctx, repo, parts)
+		if err != nil {
+			return nil, err
+		}
+		var remotes []string
+		for _, leaf := range files {
+			if _, ok := seen[leaf]; ok {
+				continue
+			}
+			seen[leaf] = struct{}{}
+			remotes = append(remotes, path.Join(dir, f.opt.Enc.ToStandardName(leaf)))
+		}
+		objects, err := f.newObjects(ctx, remotes)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range objects {
+			entries = append(entries, o)
+		}
+	}
+	if len(parts) > 0 && len(entries) == 0 {
+		return nil, fs.ErrorDirNotFound
+	}
+	return entries, nil
+}
+
+// Put the object into the repository
+//
+// Copy the reader in to the new object which is returned
+//
+// The new object may have been created if an error is returned
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	o := &Object{
+		fs:     f,
+		remote: src.Remote(),
+	}
+	return o, o.Update(ctx, in, src, options...)
+}
+
+// Mkdir creates the directory if it doesn't exist
+//
+// Directories are made from the artifacts so there is nothing to do
+// here.
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	return nil
+}
+
+// deleteItem deletes the file or directory at repoPath in repo
+func (f *Fs) deleteItem(ctx context.Context, repo, repoPath string) error {
+	opts := rest.Opts{
+		Method:     "DELETE",
+		Path:       f.itemPath(repo, repoPath),
+		NoResponse: true,
+	}
+	var resp *http.Response
+	var err error
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil && isNotFound(resp) {
+		return fs.ErrorDirNotFound
+	}
+	return err
+}
+
+// Rmdir deletes the directory if it is empty
+//
+// Returns an error if it isn't empty
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	repo, repoPath := f.split(dir)
+	if repo == "" {
+		return nil
+	}
+	if repoPath == "" {
+		return errors.Errorf("can't remove repository %q", repo)
+	}
+	entries, err := f.List(ctx, dir)
+	if err == fs.ErrorDirNotFound {
+		// directories only show in the browse service while they
+		// contain artifacts so remove any left on disk
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) != 0 {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	err = f.deleteItem(ctx, repo, repoPath)
+	if err == fs.ErrorDirNotFound {
+		return nil
+	}
+	return err
+}
+
+// Purge deletes all the files and directories in dir
+func (f *Fs) Purge(ctx context.Context, dir string) error {
+	repo, repoPath := f.split(dir)
+	if repoPath == "" {
+		// Don't empty whole repositories in one go
+		return fs.ErrorCantPurge
+	}
+	return f.deleteItem(ctx, repo, repoPath)
+}
+
+// Precision of the ModTimes in this Fs
+func (f *Fs) Precision() time.Duration {
+	return fs.ModTimeNotSupported
+}
+
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() hash.Set {
+	return hash.NewHashSet(hash.MD5, hash.SHA1)
+}
+
+// ------------------------------------------------------------
+
+// Fs returns the parent Fs
+func (o *Object) Fs() fs.Info {
+	return o.fs
+}
+
+// Return a string version
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// Remote returns the remote path
+func (o *Object) Remote() string {
+	return o.remote
+}
+
+// readChecksum reads the checksum file with extension ext stored
+// next to the object, returning "" if there isn't one
+func (o *Object) readChecksum(ctx context.Context, ext string) (checksum string, err error) {
+	repo, repoPath := o.split()
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   o.fs.itemPath(repo, repoPath+ext),
+	}
+	var resp *http.Response
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if isNotFound(resp) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer fs.CheckClose(resp.Body, &err)
+	return artifact.ReadChecksum(resp.Body)
+}
+
+// Hash returns the MD5 or SHA-1 of an object returning a lowercase hex string
+//
+// These are read from the .md5 and .sha1 files stored next to the
+// object by maven.
+func (o *Object) Hash(ctx context.Context, t hash.Type) (_ string, err error) {
+	var checksum *string
+	switch t {
+	case hash.SHA1:
+		checksum = &o.sha1
+	case hash.MD5:
+		checksum = &o.md5
+	default:
+		return "", hash.ErrUnsupported
+	}
+	if *checksum == "" {
+		*checksum, err = o.readChecksum(ctx, artifact.ChecksumExtension(t))
+	}
+	return *checksum, err
+}
+
+// Size returns the size of an object in bytes
+func (o *Object) Size() int64 {
+	return o.size
+}
+
+// setMetaData sets the metadata from the headers of resp
+func (o *Object) setMetaData(resp *http.Response) error {
+	contentType := resp.Header.Get("Content-Type")
+	// Archiva serves an HTML index for directories
+	if resp.ContentLength < 0 && strings.HasPrefix(contentType, "text/html") {
+		return fs.ErrorObjectNotFound
+	}
+	o.hasMetaData = true
+	o.size = resp.ContentLength
+	o.mimeType = contentType
+	modTime, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		modTime = timeUnset
+	}
+	o.modTime = modTime
+	return nil
+}
+
+// readMetaData gets the metadata if it hasn't already been fetched
+//
+// it also sets the info
+func (o *Object) readMetaData(ctx context.Context) error {
+	if o.hasMetaData {
+		return nil
+	}
+	repo, repoPath := o.split()
+	if repo == "" || repoPath == "" {
+		return fs.ErrorObjectNotFound
+	}
+	opts := rest.Opts{
+		Method:     "HEAD",
+		Path:       o.fs.itemPath(repo, repoPath),
+		NoResponse: true,
+	}
+	var resp *http.Response
+	var err error
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if isNotFound(resp) {
+			return fs.ErrorObjectNotFound
+		}
+		return err
+	}
+	return o.setMetaData(resp)
+}
+
+// ModTime returns the modification time of the object
+//
+// This is the time the file was last modified on the server
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	return o.modTime
+}
+
+// SetModTime sets the modification time of the object
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	return fs.ErrorCantSetModTime
+}
+
+// Storable returns a boolean showing whether this object storable
+func (o *Object) Storable() bool {
+	return true
+}
+
+// Open an object for read
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
+	repo, repoPath := o.split()
+	if o.size >= 0 {
+		fs.FixRangeOption(options, o.size)
+	}
+	opts := rest.Opts{
+		Method:  "GET",
+		Path:    o.fs.itemPath(repo, repoPath),
+		Options: options,
+	}
+	var resp *http.Response
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, err
+}
+
+// Update the object with the contents of the io.Reader, modTime and size
+//
+// Files are uploaded with a PUT to the repository file server as
+// maven does.
+//
+// The new object may have been created if an error is returned
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (err error) {
+	repo, repoPath := o.split()
+	if repo == "" || repoPath == "" {
+		return errors.New("can't upload files to the root")
+	}
+	size := src.Size()
+	opts := rest.Opts{
+		Method:     "PUT",
+		Path:       o.fs.itemPath(repo, repoPath),
+		Body:       in,
+		Options:    options,
+		NoResponse: true,
+	}
+	if size >= 0 {
+		opts.ContentLength = &size
+	}
+	var resp *http.Response
+	err = o.fs.pacer.CallNoRetry(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "upload failed")
+	}
+	o.hasMetaData = false
+	o.sha1, o.md5 = "", ""
+	return o.readMetaData(ctx)
+}
+
+// Remove an object
+func (o *Object) Remove(ctx context.Context) error {
+	repo, repoPath := o.split()
+	err := o.fs.deleteItem(ctx, repo, repoPath)
+	if err == fs.ErrorDirNotFound {
+		return fs.ErrorObjectNotFound
+	}
+	return err
+}
+
+// MimeType of an Object if known, "" otherwise
+func (o *Object) MimeType(ctx context.Context) string {
+	return o.mimeType
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs        = &Fs{}
+	_ fs.Purger    = &Fs{}
+	_ fs.Object    = &Object{}
+	_ fs.MimeTyper = &Object{}
+)
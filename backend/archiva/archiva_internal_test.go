@@ -0,0 +1,122 @@
+package archiva
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// browseResponses are the responses of the test server to the
+// browse service of the "internal" repository
+var browseResponses = map[string]string{
+	"/rootGroups":                                `{"browseResultEntries":[{"name":"org.example","project":false},{"name":"com","project":false}]}`,
+	"/browseGroupId/org":                         `{"browseResultEntries":[{"name":"org.example","project":false}]}`,
+	"/browseGroupId/org.example":                 `{"browseResultEntries":[{"name":"org.example.app","project":true},{"name":"org.example.lib.core","project":false}]}`,
+	"/versionsList/org.example/app":              `{"versions":["1.0","1.1"]}`,
+	"/artifactDownloadInfos/org.example/app/1.0": `[{"fullPath":"/repository/internal/org/example/app/1.0/app-1.0.jar"},{"path":"org/example/app/1.0/app-1.0.pom"}]`,
+}
+
+// prepare starts a test server and returns an Fs using it and the
+// number of HEAD requests made
+func prepare(t *testing.T) (*Fs, *int32, func()) {
+	var heads int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			atomic.AddInt32(&heads, 1)
+		}
+		if strings.HasPrefix(r.URL.Path, browsePath) {
+			assert.Equal(t, "internal", r.URL.Query().Get("repositoryId"))
+			assert.NotEqual(t, "", r.Header.Get("Referer"))
+			body, ok := browseResponses[strings.TrimPrefix(r.URL.Path, browsePath)]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(body))
+			return
+		}
+		switch r.URL.Path {
+		case "/repository/internal/org/example/app/1.0/app-1.0.jar", "/repository/internal/org/example/app/1.0/app-1.0.pom":
+			w.Header().Set("Content-Length", "5")
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			if r.Method == "GET" {
+				_, _ = w.Write([]byte("hello"))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ts := httptest.NewServer(handler)
+	f, err := NewFs(context.Background(), "TestArchiva", "", configmap.Simple{
+		"endpoint": ts.URL,
+	})
+	require.NoError(t, err)
+	return f.(*Fs), &heads, ts.Close
+}
+
+// listNames lists dir returning the sorted remotes
+func listNames(t *testing.T, f fs.Fs, dir string) []string {
+	entries, err := f.List(context.Background(), dir)
+	require.NoError(t, err, dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Remote())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestList(t *testing.T) {
+	f, heads, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	assert.Equal(t, []string{"internal/com", "internal/org"}, listNames(t, f, "internal"))
+	assert.Equal(t, []string{"internal/org/example"}, listNames(t, f, "internal/org"))
+	assert.Equal(t, []string{"internal/org/example/app", "internal/org/example/lib"}, listNames(t, f, "internal/org/example"))
+	assert.Equal(t, []string{"internal/org/example/app/1.0", "internal/org/example/app/1.1"}, listNames(t, f, "internal/org/example/app"))
+	assert.Equal(t, int32(0), atomic.LoadInt32(heads), "only listing files should read their metadata")
+
+	_, err := f.List(ctx, "internal/net")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+
+	// the metadata of the files is read while listing
+	entries, err := f.List(ctx, "internal/org/example/app/1.0")
+	require.NoError(t, err)
+	sort.Sort(entries)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "internal/org/example/app/1.0/app-1.0.jar", entries[0].Remote())
+	assert.Equal(t, "internal/org/example/app/1.0/app-1.0.pom", entries[1].Remote())
+	assert.Equal(t, int32(2), atomic.LoadInt32(heads))
+	for _, entry := range entries {
+		o, ok := entry.(fs.Object)
+		require.True(t, ok, entry.Remote())
+		assert.Equal(t, int64(5), o.Size())
+		assert.Equal(t, 2006, o.ModTime(ctx).Year())
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(heads), "reading the metadata again shouldn't make requests")
+}
+
+func TestNewObject(t *testing.T) {
+	f, _, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	o, err := f.NewObject(ctx, "internal/org/example/app/1.0/app-1.0.jar")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), o.Size())
+	assert.Equal(t, 2006, o.ModTime(ctx).Year())
+
+	_, err = f.NewObject(ctx, "internal/org/example/app/1.0/app-1.0.war")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+}
@@ -0,0 +1,17 @@
+// Test Archiva filesystem interface
+package archiva_test
+
+import (
+	"testing"
+
+	"github.com/rclone/rclone/backend/archiva"
+	"github.com/rclone/rclone/fstest/fstests"
+)
+
+// TestIntegration runs integration tests against the remote
+func TestIntegration(t *testing.T) {
+	fstests.Run(t, &fstests.Opt{
+		RemoteName: "TestArchiva:internal",
+		NilObject:  (*archiva.Object)(nil),
+	})
+}
@@ -0,0 +1,172 @@
+// Package api has type definitions for artifactory
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error is a single error as returned in an ErrorResponse
+type Error struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse is returned by the API when something goes wrong
+type ErrorResponse struct {
+	Errors []Error `json:"errors"`
+}
+
+// Error returns a string for the error and satisfies the error interface
+func (e *ErrorResponse) Error() string {
+	var msgs []string
+	for _, err := range e.Errors {
+		msgs = append(msgs, fmt.Sprintf("%s (%d)", err.Message, err.Status))
+	}
+	return "artifactory error: " + strings.Join(msgs, ", ")
+}
+
+// StatusCode returns the HTTP status of the first error or 0 if unknown
+func (e *ErrorResponse) StatusCode() int {
+	if len(e.Errors) == 0 {
+		return 0
+	}
+	return e.Errors[0].Status
+}
+
+// Size is a file size which Artifactory returns either as a JSON
+// number or as a string depending on the call
+type Size int64
+
+// UnmarshalJSON turns either a string or a number into a Size
+func (s *Size) UnmarshalJSON(data []byte) error {
+	str := strings.Trim(string(data), `"`)
+	if str == "" || str == "null" {
+		*s = 0
+		return nil
+	}
+	i, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return err
+	}
+	*s = Size(i)
+	return nil
+}
+
+// Repository describes a repository as returned by /api/repositories
+type Repository struct {
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+	PackageType string `json:"packageType"`
+}
+
+// Checksums are the checksums Artifactory holds for a file
+type Checksums struct {
+	SHA1   string `json:"sha1"`
+	MD5    string `json:"md5"`
+	SHA256 string `json:"sha256"`
+}
+
+// Child is an entry in the children of a FolderInfo
+type Child struct {
+	URI    string `json:"uri"`
+	Folder bool   `json:"folder"`
+}
+
+// ItemInfo is returned by /api/storage/{repo}/{path} for both files
+// and folders
+type ItemInfo struct {
+	Repo              string     `json:"repo"`
+	Path              string     `json:"path"`
+	Created           time.Time  `json:"created"`
+	CreatedBy         string     `json:"createdBy"`
+	LastModified      time.Time  `json:"lastModified"`
+	ModifiedBy        string     `json:"modifiedBy"`
+	LastUpdated       time.Time  `json:"lastUpdated"`
+	DownloadURI       string     `json:"downloadUri"`
+	MimeType          string     `json:"mimeType"`
+	Size              Size       `json:"size"`
+	Checksums         *Checksums `json:"checksums"`
+	OriginalChecksums *Checksums `json:"originalChecksums"`
+	Children          []Child    `json:"children"`
+	URI               string     `json:"uri"`
+}
+
+// IsDir returns true if the ItemInfo describes a folder
+func (i *ItemInfo) IsDir() bool {
+	return i.Checksums == nil
+}
+
+// FileListItem is an entry returned by the file list call
+type FileListItem struct {
+	URI          string    `json:"uri"`
+	Size         Size      `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	Folder       bool      `json:"folder"`
+	SHA1         string    `json:"sha1"`
+	SHA2         string    `json:"sha2"`
+}
+
+// FileList is returned by /api/storage/{repo}/{path}?list
+type FileList struct {
+	URI     string         `json:"uri"`
+	Created time.Time      `json:"created"`
+	Files   []FileListItem `json:"files"`
+}
+
+// Properties is returned by /api/storage/{repo}/{path}?properties
+type Properties struct {
+	Properties map[string][]string `json:"properties"`
+	URI        string              `json:"uri"`
+}
+
+// AQLProperty is a property of an item returned by an AQL search
+type AQLProperty struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// AQLItem is an item returned by an AQL search
+type AQLItem struct {
+	Repo       string        `json:"repo"`
+	Path       string        `json:"path"`
+	Name       string        `json:"name"`
+	Properties []AQLProperty `json:"properties"`
+}
+
+// AQLResult is returned by /api/search/aql
+type AQLResult struct {
+	Results []AQLItem `json:"results"`
+}
+
+// Message is an informational message returned by copy and move
+type Message struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// CopyMoveResponse is returned by /api/copy and /api/move
+type CopyMoveResponse struct {
+	Messages []Message `json:"messages"`
+}
+
+// Err returns the first error message in the response or nil
+func (r *CopyMoveResponse) Err() error {
+	for _, m := range r.Messages {
+		if m.Level == "ERROR" {
+			return fmt.Errorf("artifactory error: %s", m.Message)
+		}
+	}
+	return nil
+}
+
+// check interfaces
+var (
+	_ error            = (*ErrorResponse)(nil)
+	_ json.Unmarshaler = (*Size)(nil)
+)
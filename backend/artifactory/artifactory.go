@@ -0,0 +1,1102 @@
+// Package artifactory provides an interface to the JFrog Artifactory
+// repository manager.
+package artifactory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/artifactory/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/walk"
+	"github.com/rclone/rclone/lib/artifact"
+	"github.com/rclone/rclone/lib/bucket"
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+const (
+	minSleep      = 10 * time.Millisecond
+	maxSleep      = 2 * time.Second
+	decayConstant = 2 // bigger for slower decay, exponential
+)
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "artifactory",
+		Description: "JFrog Artifactory",
+		NewFs:       NewFs,
+		Options: []fs.Option{{
+			Name:     "endpoint",
+			Help:     "URL of the Artifactory server, including the context path.",
+			Required: true,
+			Examples: []fs.OptionExample{{
+				Value: "https://example.jfrog.io/artifactory",
+				Help:  "JFrog cloud instance",
+			}, {
+				Value: "https://artifactory.example.com/artifactory",
+				Help:  "Self hosted instance",
+			}},
+		}, {
+			Name: "user",
+			Help: "User name.\n\nLeave blank to use an access token instead.",
+		}, {
+			Name:       "pass",
+			Help:       "Password or API key.",
+			IsPassword: true,
+		}, {
+			Name: "access_token",
+			Help: "Access token.\n\nUsed as a bearer token instead of user and pass if set.",
+		}, {
+			Name: "checksum_deploy",
+			Help: `Try to deploy files by checksum before uploading them.
+
+If the source supplies a SHA-1 hash and Artifactory already stores a
+binary with that checksum, the file is created without transferring
+any data. If not, the file is uploaded normally.`,
+			Default:  true,
+			Advanced: true,
+		}, {
+			Name:     config.ConfigEncoding,
+			Help:     config.ConfigEncodingHelp,
+			Advanced: true,
+			Default: (encoder.Display |
+				encoder.EncodeBackSlash |
+				encoder.EncodeInvalidUtf8),
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	Endpoint       string               `config:"endpoint"`
+	User           string               `config:"user"`
+	Pass           string               `config:"pass"`
+	AccessToken    string               `config:"access_token"`
+	ChecksumDeploy bool                 `config:"checksum_deploy"`
+	Enc            encoder.MultiEncoder `config:"encoding"`
+}
+
+// Fs represents a remote artifactory server
+type Fs struct {
+	name          string       // name of this remote
+	root          string       // the path we are working on if any
+	opt           Options      // parsed config options
+	features      *fs.Features // optional features
+	srv           *rest.Client // the connection to the server
+	pacer         *fs.Pacer    // pacer for API calls
+	rootBucket    string       // repository part of root (if any)
+	rootDirectory string       // directory part of root (if any)
+}
+
+// Object describes an artifactory file
+type Object struct {
+	fs            *Fs                // what this object is part of
+	remote        string             // The remote path
+	hasMetaData   bool               // whether the item info below has been read
+	hasProperties bool               // whether the properties have been read
+	size          int64              // size of the object
+	lastModified  time.Time          // modification time on the server
+	modTime       time.Time          // modification time from the properties if set
+	checksums     artifact.Checksums // MD5 and SHA-1 of the object content
+	mimeType      string             // Content-Type of the object
+}
+
+// ------------------------------------------------------------
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	if f.rootBucket == "" {
+		return "Artifactory root"
+	}
+	if f.rootDirectory == "" {
+		return fmt.Sprintf("Artifactory repository %s", f.rootBucket)
+	}
+	return fmt.Sprintf("Artifactory repository %s path %s", f.rootBucket, f.rootDirectory)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// parsePath parses a remote 'url'
+func parsePath(path string) (root string) {
+	root = strings.Trim(path, "/")
+	return
+}
+
+// split returns repository and repositoryPath from the
+// rootRelativePath relative to f.root
+func (f *Fs) split(rootRelativePath string) (repo, repoPath string) {
+	return bucket.Split(path.Join(f.root, rootRelativePath))
+}
+
+// split returns repository and repositoryPath from the object
+func (o *Object) split() (repo, repoPath string) {
+	return o.fs.split(o.remote)
+}
+
+// setRoot changes the root of the Fs
+func (f *Fs) setRoot(root string) {
+	f.root = parsePath(root)
+	f.rootBucket, f.rootDirectory = bucket.Split(f.root)
+}
+
+// itemPath returns the URL path for repoPath in repo
+//
+// Semicolons are escaped as Artifactory treats them as the start of
+// matrix parameters.
+func (f *Fs) itemPath(repo, repoPath string) string {
+	p := rest.URLPathEscape(path.Join("/", repo, f.opt.Enc.FromStandardPath(repoPath)))
+	return strings.Replace(p, ";", "%3B", -1)
+}
+
+// retryErrorCodes is a slice of error codes that we will retry
+var retryErrorCodes = []int{
+	429, // Too Many Requests.
+	500, // Internal Server Error
+	502, // Bad Gateway
+	503, // Service Unavailable
+	504, // Gateway Timeout
+	509, // Bandwidth Limit Exceeded
+}
+
+// shouldRetry returns a boolean as to whether this resp and err
+// deserve to be retried.  It returns the err as a convenience
+func shouldRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if fserrors.ContextError(ctx, &err) {
+		return false, err
+	}
+	return fserrors.ShouldRetry(err) || fserrors.ShouldRetryHTTP(resp, retryErrorCodes), err
+}
+
+// errorHandler parses a non 2xx error response into an error
+func errorHandler(resp *http.Response) error {
+	errResponse := new(api.ErrorResponse)
+	err := rest.DecodeJSON(resp, &errResponse)
+	if err != nil {
+		fs.Debugf(nil, "Couldn't decode error response: %v", err)
+	}
+	if len(errResponse.Errors) == 0 {
+		errResponse.Errors = []api.Error{{
+			Status:  resp.StatusCode,
+			Message: resp.Status,
+		}}
+	}
+	return errResponse
+}
+
+// isNotFound returns true if resp indicates the item wasn't found
+func isNotFound(resp *http.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusNotFound
+}
+
+// isProOnly returns true if the error indicates the call needs an
+// Artifactory Pro licence
+func isProOnly(err error) bool {
+	apiErr, ok := err.(*api.ErrorResponse)
+	if !ok {
+		return false
+	}
+	for _, e := range apiErr.Errors {
+		if strings.Contains(e.Message, "Artifactory Pro") {
+			return true
+		}
+	}
+	return false
+}
+
+// NewFs constructs an Fs from the path, repository:path
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	// Parse config into Options struct
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Endpoint == "" {
+		return nil, errors.New("endpoint not set")
+	}
+	endpoint, err := url.Parse(opt.Endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't parse endpoint %q", opt.Endpoint)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, errors.Errorf("endpoint %q must start with http:// or https://", opt.Endpoint)
+	}
+	if opt.Pass != "" {
+		opt.Pass, err = obscure.Reveal(opt.Pass)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't decrypt password")
+		}
+	}
+
+	f := &Fs{
+		name:  name,
+		opt:   *opt,
+		srv:   rest.NewClient(fshttp.NewClient(ctx)).SetRoot(strings.TrimRight(opt.Endpoint, "/")),
+		pacer: fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
+	}
+	f.setRoot(root)
+	f.features = (&fs.Features{
+		ReadMimeType:            true,
+		CanHaveEmptyDirectories: true,
+		BucketBased:             true,
+		BucketBasedRootOK:       true,
+	}).Fill(ctx, f)
+	f.srv.SetErrorHandler(errorHandler)
+	if opt.AccessToken != "" {
+		f.srv.SetHeader("Authorization", "Bearer "+opt.AccessToken)
+	} else if opt.User != "" {
+		f.srv.SetUserPass(opt.User, opt.Pass)
+	}
+
+	if f.rootBucket != "" && f.rootDirectory != "" {
+		// Check to see if the (repository,directory) is actually an existing file
+		oldRoot := f.root
+		newRoot, leaf := path.Split(oldRoot)
+		f.setRoot(newRoot)
+		_, err := f.NewObject(ctx, leaf)
+		if err != nil {
+			// File doesn't exist so return old f
+			f.setRoot(oldRoot)
+			return f, nil
+		}
+		// return an error with an fs which points to the parent
+		return f, fs.ErrorIsFile
+	}
+	return f, nil
+}
+
+// readItemInfo reads the storage info for repoPath in repo
+func (f *Fs) readItemInfo(ctx context.Context, repo, repoPath string) (info *api.ItemInfo, resp *http.Response, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   "/api/storage" + f.itemPath(repo, repoPath),
+	}
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &info)
+		return shouldRetry(ctx, resp, err)
+	})
+	return info, resp, err
+}
+
+// Return an Object from a path
+//
+// If properties is not nil they are used for the modification time
+// instead of reading them when needed.
+//
+// If it can't be found it returns the error fs.ErrorObjectNotFound.
+func (f *Fs) newObjectWithInfo(ctx context.Context, remote string, info *api.FileListItem, properties artifact.Properties) (fs.Object, error) {
+	o := &Object{
+		fs:     f,
+		remote: remote,
+	}
+	if info != nil {
+		o.size = int64(info.Size)
+		o.lastModified = info.LastModified
+		o.checksums = artifact.NewChecksums("", info.SHA1)
+	} else {
+		err := o.readMetaData(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if properties != nil {
+		o.setProperties(properties)
+	}
+	return o, nil
+}
+
+// NewObject finds the Object at remote.  If it can't be found
+// it returns the error fs.ErrorObjectNotFound.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	return f.newObjectWithInfo(ctx, remote, nil, nil)
+}
+
+// listRepositories lists the repositories as directories
+func (f *Fs) listRepositories(ctx context.Context) (entries fs.DirEntries, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   "/api/repositories",
+	}
+	var repos []api.Repository
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &repos)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list repositories")
+	}
+	for _, repo := range repos {
+		entries = append(entries, fs.NewDir(repo.Key, time.Time{}))
+	}
+	return entries, nil
+}
+
+// searchProperties reads the properties of the files in directory of
+// repo, and of those below it if deep is set, with a single AQL
+// search rather than one request per file.
+//
+// The properties are returned keyed by the path of the file relative
+// to directory as the server sees it.
+func (f *Fs) searchProperties(ctx context.Context, repo, directory string, deep bool) (map[string]artifact.Properties, error) {
+	dirPath := f.opt.Enc.FromStandardPath(directory)
+	criteria := map[string]interface{}{
+		"repo": repo,
+		"type": "file",
+	}
+	switch {
+	case !deep && dirPath == "":
+		criteria["path"] = "."
+	case !deep:
+		criteria["path"] = dirPath
+	case dirPath != "":
+		criteria["$or"] = []map[string]interface{}{
+			{"path": dirPath},
+			{"path": map[string]string{"$match": dirPath + "/*"}},
+		}
+	}
+	criteriaJSON, err := json.Marshal(criteria)
+	if err != nil {
+		return nil, err
+	}
+	query := "items.find(" + string(criteriaJSON) + `).include("repo","path","name","property")`
+	opts := rest.Opts{
+		Method:      "POST",
+		Path:        "/api/search/aql",
+		ContentType: "text/plain",
+	}
+	var result api.AQLResult
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		opts.Body = strings.NewReader(query)
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &result)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	prefix := ""
+	if dirPath != "" {
+		prefix = dirPath + "/"
+	}
+	properties := make(map[string]artifact.Properties, len(result.Results))
+	for _, item := range result.Results {
+		itemProperties := artifact.Properties{}
+		for _, property := range item.Properties {
+			itemProperties[property.Key] = append(itemProperties[property.Key], property.Value)
+		}
+		properties[strings.TrimPrefix(path.Join(item.Path, item.Name), prefix)] = itemProperties
+	}
+	return properties, nil
+}
+
+// listFn is called from list to handle an object.
+//
+// properties are nil for folders or if they couldn't be read.
+type listFn func(remote string, item *api.FileListItem, properties artifact.Properties) error
+
+// list the objects in dir of repo, recursing if deep is set, calling
+// fn for each one. The remotes passed to fn are relative to dir.
+func (f *Fs) list(ctx context.Context, repo, directory string, deep bool, fn listFn) error {
+	deepFlag := "0"
+	if deep {
+		deepFlag = "1"
+	}
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   "/api/storage" + f.itemPath(repo, directory),
+		Parameters: url.Values{
+			"list":        {""},
+			"deep":        {deepFlag},
+			"listFolders": {"1"},
+		},
+	}
+	var result api.FileList
+	var resp *http.Response
+	var err error
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &result)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if isNotFound(resp) {
+			return fs.ErrorDirNotFound
+		}
+		return errors.Wrap(err, "failed to list files")
+	}
+	// Read the properties of all the files at once if there are any
+	var properties map[string]artifact.Properties
+	for i := range result.Files {
+		if !result.Files[i].Folder {
+			properties, err = f.searchProperties(ctx, repo, directory, deep)
+			if err != nil {
+				fs.Debugf(f, "Failed to search properties - reading them per file: %v", err)
+			}
+			break
+		}
+	}
+	for i := range result.Files {
+		item := &result.Files[i]
+		itemPath := strings.TrimPrefix(item.URI, "/")
+		remote := f.opt.Enc.ToStandardPath(itemPath)
+		if remote == "" {
+			continue
+		}
+		var itemProperties artifact.Properties
+		if properties != nil && !item.Folder {
+			itemProperties = properties[itemPath]
+			if itemProperties == nil {
+				itemProperties = artifact.Properties{}
+			}
+		}
+		err = fn(remote, item, itemProperties)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// itemToDirEntry converts an item listed in dir into an fs.DirEntry
+func (f *Fs) itemToDirEntry(ctx context.Context, dir, remote string, item *api.FileListItem, properties artifact.Properties) (fs.DirEntry, error) {
+	remote = path.Join(dir, remote)
+	if item.Folder {
+		return fs.NewDir(remote, item.LastModified), nil
+	}
+	return f.newObjectWithInfo(ctx, remote, item, properties)
+}
+
+// List the objects and directories in dir into entries.  The
+// entries can be returned in any order but should be for a
+// complete directory.
+//
+// dir should be "" to list the root, and should not have
+// trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	repo, directory := f.split(dir)
+	if repo == "" {
+		if directory != "" {
+			return nil, fs.ErrorListBucketRequired
+		}
+		return f.listRepositories(ctx)
+	}
+	err = f.list(ctx, repo, directory, false, func(remote string, item *api.FileListItem, properties artifact.Properties) error {
+		entry, err := f.itemToDirEntry(ctx, dir, remote, item, properties)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListR lists the objects and directories of the Fs starting
+// from dir recursively into out.
+//
+// dir should be "" to start from the root, and should not
+// have trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+//
+// It should call callback for each tranche of entries read.
+// These need not be returned in any particular order.  If
+// callback returns an error then the listing will stop
+// immediately.
+//
+// Don't implement this unless you have a more efficient way
+// of listing recursively than doing a directory traversal.
+func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (err error) {
+	repo, directory := f.split(dir)
+	list := walk.NewListRHelper(callback)
+	listR := func(repo, directory, prefix string) error {
+		return f.list(ctx, repo, directory, true, func(remote string, item *api.FileListItem, properties artifact.Properties) error {
+			entry, err := f.itemToDirEntry(ctx, prefix, remote, item, properties)
+			if err != nil {
+				return err
+			}
+			return list.Add(entry)
+		})
+	}
+	if repo == "" {
+		if directory != "" {
+			return fs.ErrorListBucketRequired
+		}
+		entries, err := f.listRepositories(ctx)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			err = list.Add(entry)
+			if err != nil {
+				return err
+			}
+			repo := entry.Remote()
+			err = listR(repo, "", repo)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err = listR(repo, directory, dir)
+		if err != nil {
+			return err
+		}
+	}
+	return list.Flush()
+}
+
+// Put the object into the repository
+//
+// Copy the reader in to the new object which is returned
+//
+// The new object may have been created if an error is returned
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	o := &Object{
+		fs:     f,
+		remote: src.Remote(),
+	}
+	return o, o.Update(ctx, in, src, options...)
+}
+
+// Mkdir creates the directory if it doesn't exist
+//
+// Repositories can't be created so this returns an error if the
+// repository doesn't exist.
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	repo, repoPath := f.split(dir)
+	if repo == "" {
+		return nil
+	}
+	if repoPath == "" {
+		_, resp, err := f.readItemInfo(ctx, repo, "")
+		if err != nil {
+			if isNotFound(resp) {
+				return errors.Errorf("repository %q not found - create it in Artifactory first", repo)
+			}
+			return err
+		}
+		return nil
+	}
+	opts := rest.Opts{
+		Method:     "PUT",
+		Path:       f.itemPath(repo, repoPath) + "/",
+		NoResponse: true,
+	}
+	return f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+}
+
+// deleteItem deletes the file or folder at repoPath in repo
+func (f *Fs) deleteItem(ctx context.Context, repo, repoPath string) error {
+	opts := rest.Opts{
+		Method:     "DELETE",
+		Path:       f.itemPath(repo, repoPath),
+		NoResponse: true,
+	}
+	var resp *http.Response
+	var err error
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil && isNotFound(resp) {
+		return fs.ErrorDirNotFound
+	}
+	return err
+}
+
+// Rmdir deletes the directory if it is empty
+//
+// Returns an error if it isn't empty
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	repo, repoPath := f.split(dir)
+	if repo == "" {
+		return nil
+	}
+	if repoPath == "" {
+		return errors.Errorf("can't remove repository %q", repo)
+	}
+	entries, err := f.List(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) != 0 {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	return f.deleteItem(ctx, repo, repoPath)
+}
+
+// Purge deletes all the files and directories including the old versions.
+func (f *Fs) Purge(ctx context.Context, dir string) error {
+	repo, repoPath := f.split(dir)
+	if repoPath == "" {
+		// Don't empty whole repositories in one go
+		return fs.ErrorCantPurge
+	}
+	return f.deleteItem(ctx, repo, repoPath)
+}
+
+// Precision of the ModTimes in this Fs
+func (f *Fs) Precision() time.Duration {
+	return time.Millisecond
+}
+
+// copyOrMove copies or moves srcRepo/srcPath to dstRepo/dstPath
+// with method "copy" or "move"
+func (f *Fs) copyOrMove(ctx context.Context, method, srcRepo, srcPath, dstRepo, dstPath string) error {
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/api/" + method + f.itemPath(srcRepo, srcPath),
+		Parameters: url.Values{
+			"to":              {path.Join("/", dstRepo, f.opt.Enc.FromStandardPath(dstPath))},
+			"suppressLayouts": {"1"},
+			"failFast":        {"1"},
+		},
+	}
+	var result api.CopyMoveResponse
+	var resp *http.Response
+	var err error
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &result)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return err
+	}
+	return result.Err()
+}
+
+// Copy src to this remote using server-side copy operations.
+//
+// This is stored with the remote path given
+//
+// It returns the destination Object and a possible error
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantCopy
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debugf(src, "Can't copy - not same remote type")
+		return nil, fs.ErrorCantCopy
+	}
+	srcRepo, srcPath := srcObj.split()
+	dstRepo, dstPath := f.split(remote)
+	err := f.copyOrMove(ctx, "copy", srcRepo, srcPath, dstRepo, dstPath)
+	if err != nil {
+		if isProOnly(err) {
+			return nil, fs.ErrorCantCopy
+		}
+		return nil, errors.Wrap(err, "copy failed")
+	}
+	return f.NewObject(ctx, remote)
+}
+
+// Move src to this remote using server-side move operations.
+//
+// This is stored with the remote path given
+//
+// It returns the destination Object and a possible error
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantMove
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debugf(src, "Can't move - not same remote type")
+		return nil, fs.ErrorCantMove
+	}
+	srcRepo, srcPath := srcObj.split()
+	dstRepo, dstPath := f.split(remote)
+	err := f.copyOrMove(ctx, "move", srcRepo, srcPath, dstRepo, dstPath)
+	if err != nil {
+		if isProOnly(err) {
+			return nil, fs.ErrorCantMove
+		}
+		return nil, errors.Wrap(err, "move failed")
+	}
+	return f.NewObject(ctx, remote)
+}
+
+// DirMove moves src, srcRemote to this remote at dstRemote
+// using server-side move operations.
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantDirMove
+//
+// If destination exists then return fs.ErrorDirExists
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string) error {
+	srcFs, ok := src.(*Fs)
+	if !ok {
+		fs.Debugf(srcFs, "Can't move directory - not same remote type")
+		return fs.ErrorCantDirMove
+	}
+	srcRepo, srcPath := srcFs.split(srcRemote)
+	dstRepo, dstPath := f.split(dstRemote)
+	if srcPath == "" || dstPath == "" {
+		fs.Debugf(srcFs, "Can't move repositories")
+		return fs.ErrorCantDirMove
+	}
+	_, resp, err := f.readItemInfo(ctx, dstRepo, dstPath)
+	if err == nil {
+		return fs.ErrorDirExists
+	} else if !isNotFound(resp) {
+		return err
+	}
+	err = f.copyOrMove(ctx, "move", srcRepo, srcPath, dstRepo, dstPath)
+	if err != nil {
+		if isProOnly(err) {
+			return fs.ErrorCantDirMove
+		}
+		return errors.Wrap(err, "dirmove failed")
+	}
+	return nil
+}
+
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() hash.Set {
+	return artifact.ChecksumTypes()
+}
+
+// ------------------------------------------------------------
+
+// Fs returns the parent Fs
+func (o *Object) Fs() fs.Info {
+	return o.fs
+}
+
+// Return a string version
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// Remote returns the remote path
+func (o *Object) Remote() string {
+	return o.remote
+}
+
+// Hash returns the MD5 or SHA-1 of an object returning a lowercase hex string
+func (o *Object) Hash(ctx context.Context, t hash.Type) (string, error) {
+	checksum := o.checksums.Ref(t)
+	if checksum == nil {
+		return "", hash.ErrUnsupported
+	}
+	if *checksum == "" && !o.hasMetaData {
+		if err := o.readMetaData(ctx); err != nil {
+			return "", err
+		}
+	}
+	return *checksum, nil
+}
+
+// Size returns the size of an object in bytes
+func (o *Object) Size() int64 {
+	return o.size
+}
+
+// setMetaData sets the metadata from info
+func (o *Object) setMetaData(info *api.ItemInfo) error {
+	if info.IsDir() {
+		return fs.ErrorObjectNotFound
+	}
+	o.hasMetaData = true
+	o.size = int64(info.Size)
+	o.lastModified = info.LastModified
+	o.checksums = artifact.NewChecksums(info.Checksums.MD5, info.Checksums.SHA1)
+	o.mimeType = info.MimeType
+	return nil
+}
+
+// readMetaData gets the metadata if it hasn't already been fetched
+//
+// it also sets the info
+func (o *Object) readMetaData(ctx context.Context) error {
+	if o.hasMetaData {
+		return nil
+	}
+	repo, repoPath := o.split()
+	if repo == "" || repoPath == "" {
+		return fs.ErrorObjectNotFound
+	}
+	info, resp, err := o.fs.readItemInfo(ctx, repo, repoPath)
+	if err != nil {
+		if isNotFound(resp) {
+			return fs.ErrorObjectNotFound
+		}
+		return err
+	}
+	return o.setMetaData(info)
+}
+
+// readProperties reads the modification time property if it hasn't
+// already been read
+func (o *Object) readProperties(ctx context.Context) error {
+	if o.hasProperties {
+		return nil
+	}
+	repo, repoPath := o.split()
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   "/api/storage" + o.fs.itemPath(repo, repoPath),
+		Parameters: url.Values{
+			"properties": {artifact.ModTimeProperty},
+		},
+	}
+	var result api.Properties
+	var resp *http.Response
+	var err error
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.CallJSON(ctx, &opts, nil, &result)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		// Artifactory returns 404 if there are no properties
+		if isNotFound(resp) {
+			o.hasProperties = true
+			return nil
+		}
+		return err
+	}
+	o.setProperties(result.Properties)
+	return nil
+}
+
+// setProperties sets the modification time from properties
+func (o *Object) setProperties(properties artifact.Properties) {
+	o.hasProperties = true
+	modTime, err := properties.ModTime()
+	if err != nil {
+		fs.Debugf(o, "Failed to read modification time: %v", err)
+	} else {
+		o.modTime = modTime
+	}
+}
+
+// ModTime returns the modification time of the object
+//
+// It attempts to read the objects mtime property and if that isn't
+// present the last modified time on the server
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	err := o.readProperties(ctx)
+	if err != nil {
+		fs.Logf(o, "Failed to read properties: %v", err)
+	}
+	if !o.modTime.IsZero() {
+		return o.modTime
+	}
+	return o.lastModified
+}
+
+// SetModTime sets the modification time of the object
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	repo, repoPath := o.split()
+	properties := artifact.Properties{}
+	properties.SetModTime(modTime)
+	opts := rest.Opts{
+		Method: "PUT",
+		Path:   "/api/storage" + o.fs.itemPath(repo, repoPath),
+		Parameters: url.Values{
+			"properties": {properties.Query()},
+			"recursive":  {"0"},
+		},
+		NoResponse: true,
+	}
+	err := o.fs.pacer.Call(func() (bool, error) {
+		resp, err := o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return err
+	}
+	o.modTime = modTime
+	o.hasProperties = true
+	return nil
+}
+
+// Storable returns a boolean showing whether this object storable
+func (o *Object) Storable() bool {
+	return true
+}
+
+// Open an object for read
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
+	repo, repoPath := o.split()
+	fs.FixRangeOption(options, o.size)
+	opts := rest.Opts{
+		Method:  "GET",
+		Path:    o.fs.itemPath(repo, repoPath),
+		Options: options,
+	}
+	var resp *http.Response
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, err
+}
+
+// deployByChecksum tries to create the object from a binary Artifactory
+// already stores. It returns false if the checksum wasn't found.
+func (o *Object) deployByChecksum(ctx context.Context, uploadPath, sha1 string, info *api.ItemInfo) (bool, error) {
+	var zero int64
+	opts := rest.Opts{
+		Method:        "PUT",
+		Path:          uploadPath,
+		ContentLength: &zero,
+		ExtraHeaders: map[string]string{
+			"X-Checksum-Deploy": "true",
+			"X-Checksum-Sha1":   sha1,
+		},
+	}
+	var resp *http.Response
+	var err error
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.CallJSON(ctx, &opts, nil, info)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if isNotFound(resp) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Update the object with the contents of the io.Reader, modTime and size
+//
+// The new object may have been created if an error is returned
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (err error) {
+	repo, repoPath := o.split()
+	if repo == "" || repoPath == "" {
+		return errors.New("can't upload files to the root")
+	}
+	size := src.Size()
+	modTime := src.ModTime(ctx)
+	// Set the modification time as a matrix parameter
+	properties := artifact.Properties{}
+	properties.SetModTime(modTime)
+	uploadPath := o.fs.itemPath(repo, repoPath) + properties.Matrix()
+
+	sha1, _ := src.Hash(ctx, hash.SHA1)
+	md5, _ := src.Hash(ctx, hash.MD5)
+	var info api.ItemInfo
+	if o.fs.opt.ChecksumDeploy && sha1 != "" && size > 0 {
+		ok, err := o.deployByChecksum(ctx, uploadPath, sha1, &info)
+		if err != nil {
+			return errors.Wrap(err, "checksum deploy failed")
+		}
+		if ok {
+			fs.Debugf(o, "Deployed by checksum")
+			o.modTime = modTime
+			o.hasProperties = true
+			return o.setMetaData(&info)
+		}
+	}
+
+	opts := rest.Opts{
+		Method:       "PUT",
+		Path:         uploadPath,
+		Body:         in,
+		ExtraHeaders: map[string]string{},
+		Options:      options,
+	}
+	if size >= 0 {
+		opts.ContentLength = &size
+	}
+	// Let the server verify the content if we can
+	if sha1 != "" {
+		opts.ExtraHeaders["X-Checksum-Sha1"] = sha1
+	}
+	if md5 != "" {
+		opts.ExtraHeaders["X-Checksum-Md5"] = md5
+	}
+	var resp *http.Response
+	err = o.fs.pacer.CallNoRetry(func() (bool, error) {
+		resp, err = o.fs.srv.CallJSON(ctx, &opts, nil, &info)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "upload failed")
+	}
+	o.modTime = modTime
+	o.hasProperties = true
+	return o.setMetaData(&info)
+}
+
+// Remove an object
+func (o *Object) Remove(ctx context.Context) error {
+	repo, repoPath := o.split()
+	err := o.fs.deleteItem(ctx, repo, repoPath)
+	if err == fs.ErrorDirNotFound {
+		return fs.ErrorObjectNotFound
+	}
+	return err
+}
+
+// MimeType of an Object if known, "" otherwise
+func (o *Object) MimeType(ctx context.Context) string {
+	return o.mimeType
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs        = &Fs{}
+	_ fs.Copier    = &Fs{}
+	_ fs.Mover     = &Fs{}
+	_ fs.DirMover  = &Fs{}
+	_ fs.Purger    = &Fs{}
+	_ fs.ListRer   = &Fs{}
+	_ fs.Object    = &Object{}
+	_ fs.MimeTyper = &Object{}
+)
@@ -0,0 +1,284 @@
+package artifactory
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/artifactory/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lastModified is the time on the server of all the test files
+var lastModified = time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+
+// storedSHA1 is the SHA-1 of a binary the test server already stores
+const storedSHA1 = "a9993e364706816aba3e25717850c26c9cd0d89d"
+
+// testServer records the requests made to the mock API
+type testServer struct {
+	t           *testing.T
+	mu          sync.Mutex
+	aqlFails    bool     // whether AQL searches fail
+	aqlQueries  []string // bodies of the AQL searches
+	propertyGET int      // number of per file property reads
+	puts        []string // paths of the uploads
+	deploys     []string // paths of the checksum deploys
+}
+
+// hashOf returns the hex encoded hash of content
+func hashOf(t hash.Type, content string) string {
+	if t == hash.MD5 {
+		sum := md5.Sum([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+	sum := sha1.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeJSON writes v as the JSON response with status
+func writeJSON(t *testing.T, w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	assert.NoError(t, json.NewEncoder(w).Encode(v))
+}
+
+// writeError writes an Artifactory error response
+func writeError(t *testing.T, w http.ResponseWriter, status int, message string) {
+	writeJSON(t, w, status, api.ErrorResponse{Errors: []api.Error{{Status: status, Message: message}}})
+}
+
+// fileInfo returns the item info of an uploaded file
+func fileInfo(repoPath, sha1, md5 string) api.ItemInfo {
+	return api.ItemInfo{
+		Repo:         "repo",
+		Path:         repoPath,
+		LastModified: lastModified,
+		Size:         3,
+		Checksums:    &api.Checksums{SHA1: sha1, MD5: md5},
+	}
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.t
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/api/storage/repo/dir" && r.URL.Query().Get("deep") == "0":
+		writeJSON(t, w, http.StatusOK, api.FileList{
+			URI: "/repo/dir",
+			Files: []api.FileListItem{
+				{URI: "/a.txt", Size: 3, LastModified: lastModified, SHA1: strings.ToUpper(storedSHA1)},
+				{URI: "/b.txt", Size: 5, LastModified: lastModified, SHA1: hashOf(hash.SHA1, "hello")},
+				{URI: "/sub", LastModified: lastModified, Folder: true},
+			},
+		})
+	case r.Method == "POST" && r.URL.Path == "/api/search/aql":
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		s.aqlQueries = append(s.aqlQueries, string(body))
+		if s.aqlFails {
+			writeError(t, w, http.StatusBadRequest, "Failed to parse query")
+			return
+		}
+		writeJSON(t, w, http.StatusOK, api.AQLResult{
+			Results: []api.AQLItem{{
+				Repo: "repo",
+				Path: "dir",
+				Name: "a.txt",
+				Properties: []api.AQLProperty{
+					{Key: "rclone.mtime", Value: "2021-01-02T03:04:05.123456789Z"},
+					{Key: "other", Value: "x"},
+				},
+			}},
+		})
+	case r.Method == "GET" && r.URL.Query().Get("properties") != "":
+		s.propertyGET++
+		if r.URL.Path != "/api/storage/repo/dir/a.txt" {
+			writeError(t, w, http.StatusNotFound, "No properties could be found.")
+			return
+		}
+		writeJSON(t, w, http.StatusOK, api.Properties{
+			Properties: map[string][]string{"rclone.mtime": {"2021-01-02T03:04:05.123456789Z"}},
+		})
+	case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/repo/"):
+		repoPath := strings.TrimPrefix(r.URL.Path, "/repo/")
+		if r.Header.Get("X-Checksum-Deploy") == "true" {
+			s.deploys = append(s.deploys, r.URL.Path)
+			if r.Header.Get("X-Checksum-Sha1") != storedSHA1 {
+				writeError(t, w, http.StatusNotFound, "Checksum deploy failed")
+				return
+			}
+			writeJSON(t, w, http.StatusCreated, fileInfo(repoPath, storedSHA1, hashOf(hash.MD5, "abc")))
+			return
+		}
+		s.puts = append(s.puts, r.URL.Path)
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		sha1 := hashOf(hash.SHA1, string(body))
+		if checksum := r.Header.Get("X-Checksum-Sha1"); checksum != "" {
+			assert.Equal(t, sha1, checksum)
+		}
+		writeJSON(t, w, http.StatusCreated, fileInfo(repoPath, strings.ToUpper(sha1), hashOf(hash.MD5, string(body))))
+	default:
+		t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		writeError(t, w, http.StatusNotFound, "Not found")
+	}
+}
+
+// prepare starts a test server and returns an Fs using it
+func prepare(t *testing.T) (*Fs, *testServer, func()) {
+	s := &testServer{t: t}
+	ts := httptest.NewServer(s)
+	f, err := NewFs(context.Background(), "TestArtifactory", "repo", configmap.Simple{
+		"endpoint":        ts.URL,
+		"checksum_deploy": "true",
+	})
+	require.NoError(t, err)
+	return f.(*Fs), s, ts.Close
+}
+
+// listNames lists dir returning the sorted remotes
+func listNames(t *testing.T, f fs.Fs, dir string) []string {
+	entries, err := f.List(context.Background(), dir)
+	require.NoError(t, err, dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Remote())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// listObject lists dir and returns the object remote
+func listObject(t *testing.T, f fs.Fs, dir, remote string) fs.Object {
+	entries, err := f.List(context.Background(), dir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		if o, ok := entry.(fs.Object); ok && o.Remote() == remote {
+			return o
+		}
+	}
+	t.Fatalf("%q not found in listing of %q", remote, dir)
+	return nil
+}
+
+func TestListProperties(t *testing.T) {
+	ctx := context.Background()
+	f, s, tidy := prepare(t)
+	defer tidy()
+
+	assert.Equal(t, []string{"dir/a.txt", "dir/b.txt", "dir/sub"}, listNames(t, f, "dir"))
+	require.Len(t, s.aqlQueries, 1)
+	assert.Equal(t, `items.find({"path":"dir","repo":"repo","type":"file"}).include("repo","path","name","property")`, s.aqlQueries[0])
+
+	a := listObject(t, f, "dir", "dir/a.txt")
+	b := listObject(t, f, "dir", "dir/b.txt")
+	assert.Equal(t, time.Date(2021, 1, 2, 3, 4, 5, 123456789, time.UTC), a.ModTime(ctx))
+	assert.Equal(t, lastModified, b.ModTime(ctx))
+	assert.Equal(t, 0, s.propertyGET, "properties should come from the AQL search")
+
+	// the SHA-1 from the listing is normalised
+	sha1, err := a.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, storedSHA1, sha1)
+}
+
+func TestListPropertiesFallback(t *testing.T) {
+	ctx := context.Background()
+	f, s, tidy := prepare(t)
+	defer tidy()
+	s.aqlFails = true
+
+	a := listObject(t, f, "dir", "dir/a.txt")
+	b := listObject(t, f, "dir", "dir/b.txt")
+	assert.Equal(t, 0, s.propertyGET)
+	assert.Equal(t, time.Date(2021, 1, 2, 3, 4, 5, 123456789, time.UTC), a.ModTime(ctx))
+	assert.Equal(t, lastModified, b.ModTime(ctx))
+	assert.Equal(t, 2, s.propertyGET)
+	// the properties are only read once
+	a.ModTime(ctx)
+	assert.Equal(t, 2, s.propertyGET)
+}
+
+func TestSearchPropertiesDeep(t *testing.T) {
+	f, s, tidy := prepare(t)
+	defer tidy()
+
+	_, err := f.searchProperties(context.Background(), "repo", "dir", true)
+	require.NoError(t, err)
+	_, err = f.searchProperties(context.Background(), "repo", "", true)
+	require.NoError(t, err)
+	_, err = f.searchProperties(context.Background(), "repo", "", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`items.find({"$or":[{"path":"dir"},{"path":{"$match":"dir/*"}}],"repo":"repo","type":"file"}).include("repo","path","name","property")`,
+		`items.find({"repo":"repo","type":"file"}).include("repo","path","name","property")`,
+		`items.find({"path":".","repo":"repo","type":"file"}).include("repo","path","name","property")`,
+	}, s.aqlQueries)
+}
+
+func TestPut(t *testing.T) {
+	ctx := context.Background()
+	f, s, tidy := prepare(t)
+	defer tidy()
+	modTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	const wantPath = "/repo/dir/new.txt;rclone.mtime=2021-01-02T03:04:05Z"
+
+	// a binary the server already stores is deployed by checksum
+	src := object.NewStaticObjectInfo("dir/new.txt", modTime, 3, true, map[hash.Type]string{
+		hash.SHA1: storedSHA1,
+	}, nil)
+	o, err := f.Put(ctx, strings.NewReader("abc"), src)
+	require.NoError(t, err)
+	assert.Equal(t, []string{wantPath}, s.deploys)
+	assert.Empty(t, s.puts)
+	assert.Equal(t, modTime, o.ModTime(ctx))
+	md5, err := o.Hash(ctx, hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, hashOf(hash.MD5, "abc"), md5)
+
+	// otherwise it falls back to an upload
+	src = object.NewStaticObjectInfo("dir/new.txt", modTime, 3, true, map[hash.Type]string{
+		hash.SHA1: hashOf(hash.SHA1, "xyz"),
+	}, nil)
+	o, err = f.Put(ctx, strings.NewReader("xyz"), src)
+	require.NoError(t, err)
+	assert.Equal(t, []string{wantPath, wantPath}, s.deploys)
+	assert.Equal(t, []string{wantPath}, s.puts)
+	sha1, err := o.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, hashOf(hash.SHA1, "xyz"), sha1)
+
+	// no checksum deploy without a SHA-1
+	src = object.NewStaticObjectInfo("dir/new.txt", modTime, 3, true, nil, nil)
+	_, err = f.Put(ctx, strings.NewReader("xyz"), src)
+	require.NoError(t, err)
+	assert.Len(t, s.deploys, 2)
+	assert.Len(t, s.puts, 2)
+
+	// disabled by the option
+	f.opt.ChecksumDeploy = false
+	src = object.NewStaticObjectInfo("dir/new.txt", modTime, 3, true, map[hash.Type]string{
+		hash.SHA1: storedSHA1,
+	}, nil)
+	_, err = f.Put(ctx, strings.NewReader("abc"), src)
+	require.NoError(t, err)
+	assert.Len(t, s.deploys, 2)
+	assert.Len(t, s.puts, 3)
+}
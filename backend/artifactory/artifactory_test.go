@@ -0,0 +1,17 @@
+// Test Artifactory filesystem interface
+package artifactory_test
+
+import (
+	"testing"
+
+	"github.com/rclone/rclone/backend/artifactory"
+	"github.com/rclone/rclone/fstest/fstests"
+)
+
+// TestIntegration runs integration tests against the remote
+func TestIntegration(t *testing.T) {
+	fstests.Run(t, &fstests.Opt{
+		RemoteName: "TestArtifactory:rclone-test",
+		NilObject:  (*artifactory.Object)(nil),
+	})
+}
@@ -0,0 +1,1083 @@
+// Package codeartifact provides an interface to the package
+// repositories of an AWS CodeArtifact domain.
+package codeartifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/codeartifact"
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/artifact"
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/readers"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+const (
+	minSleep      = 10 * time.Millisecond
+	maxSleep      = 2 * time.Second
+	decayConstant = 2 // bigger for slower decay, exponential
+
+	// noNamespace is the directory used for packages without a namespace
+	noNamespace = "-"
+	// tokenExpiryWindow is how long before it expires the
+	// authorization token is renewed
+	tokenExpiryWindow = 5 * time.Minute
+	// maxDeleteVersions is the most versions DeletePackageVersions
+	// accepts in one call
+	maxDeleteVersions = 100
+)
+
+// formats are the package formats shown in each repository
+var formats = []string{
+	codeartifact.PackageFormatMaven,
+	codeartifact.PackageFormatNpm,
+	codeartifact.PackageFormatNuget,
+	codeartifact.PackageFormatPypi,
+}
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "codeartifact",
+		Description: "AWS CodeArtifact",
+		NewFs:       NewFs,
+		Options: []fs.Option{{
+			Name:     "domain",
+			Help:     "Name of the CodeArtifact domain.",
+			Required: true,
+		}, {
+			Name: "domain_owner",
+			Help: "AWS account ID that owns the domain.\n\nLeave blank if the domain is owned by the account of the credentials.",
+		}, {
+			Name:    "env_auth",
+			Help:    "Get AWS credentials from runtime (environment variables or EC2/ECS meta data if no env vars).\nOnly applies if access_key_id and secret_access_key is blank.",
+			Default: false,
+			Examples: []fs.OptionExample{{
+				Value: "false",
+				Help:  "Enter AWS credentials in the next step",
+			}, {
+				Value: "true",
+				Help:  "Get AWS credentials from the environment (env vars or IAM)",
+			}},
+		}, {
+			Name: "access_key_id",
+			Help: "AWS Access Key ID.\nLeave blank to use runtime credentials.",
+		}, {
+			Name: "secret_access_key",
+			Help: "AWS Secret Access Key (password)\nLeave blank to use runtime credentials.",
+		}, {
+			Name: "session_token",
+			Help: "An AWS session token",
+		}, {
+			Name:     "region",
+			Help:     "Region the domain is in.",
+			Required: true,
+			Examples: []fs.OptionExample{{
+				Value: "us-east-1",
+				Help:  "US East (N. Virginia)",
+			}, {
+				Value: "us-east-2",
+				Help:  "US East (Ohio)",
+			}, {
+				Value: "us-west-2",
+				Help:  "US West (Oregon)",
+			}, {
+				Value: "eu-west-1",
+				Help:  "Europe (Ireland)",
+			}, {
+				Value: "eu-central-1",
+				Help:  "Europe (Frankfurt)",
+			}, {
+				Value: "ap-southeast-2",
+				Help:  "Asia Pacific (Sydney)",
+			}},
+		}, {
+			Name:     "endpoint",
+			Help:     "Endpoint for the CodeArtifact API.\n\nLeave blank to use the default endpoint for the region.",
+			Advanced: true,
+		}, {
+			Name:     config.ConfigEncoding,
+			Help:     config.ConfigEncodingHelp,
+			Advanced: true,
+			Default: (encoder.Display |
+				encoder.EncodeBackSlash |
+				encoder.EncodeInvalidUtf8),
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	Domain          string               `config:"domain"`
+	DomainOwner     string               `config:"domain_owner"`
+	EnvAuth         bool                 `config:"env_auth"`
+	AccessKeyID     string               `config:"access_key_id"`
+	SecretAccessKey string               `config:"secret_access_key"`
+	SessionToken    string               `config:"session_token"`
+	Region          string               `config:"region"`
+	Endpoint        string               `config:"endpoint"`
+	Enc             encoder.MultiEncoder `config:"encoding"`
+}
+
+// Fs represents the repositories of a CodeArtifact domain
+type Fs struct {
+	name        string                     // name of this remote
+	root        string                     // the path we are working on if any
+	opt         Options                    // parsed config options
+	features    *fs.Features               // optional features
+	c           *codeartifact.CodeArtifact // the connection to the CodeArtifact API
+	srv         *rest.Client               // the connection to the repository endpoints
+	pacer       *fs.Pacer                  // pacer for API calls
+	tokenMu     sync.Mutex                 // protects the fields below
+	token       string                     // authorization token for the repository endpoints
+	tokenExpiry time.Time                  // when token expires
+	endpoints   map[string]string          // repository endpoint by repository and format
+}
+
+// Object describes an asset of a package version
+type Object struct {
+	fs        *Fs                // what this object is part of
+	remote    string             // The remote path
+	size      int64              // size of the object
+	modTime   time.Time          // publication time of the package version
+	checksums artifact.Checksums // MD5 and SHA-1 of the object content
+}
+
+// coordinates are the parts of a path in the domain
+//
+// The layout is repository/format/namespace/package/version/asset
+// with the namespace set to noNamespace for packages without one.
+type coordinates struct {
+	depth     int // number of parts set
+	repo      string
+	format    string
+	namespace string
+	pkg       string
+	version   string
+	asset     string
+}
+
+// ------------------------------------------------------------
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	if f.root == "" {
+		return fmt.Sprintf("CodeArtifact domain %s", f.opt.Domain)
+	}
+	return fmt.Sprintf("CodeArtifact domain %s path %s", f.opt.Domain, f.root)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// parsePath parses a remote 'url'
+func parsePath(path string) (root string) {
+	root = strings.Trim(path, "/")
+	return
+}
+
+// parseCoordinates splits p into coordinates
+//
+// It returns fs.ErrorDirNotFound if p is deeper than an asset.
+func parseCoordinates(p string) (c coordinates, err error) {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return c, nil
+	}
+	parts := strings.Split(p, "/")
+	if len(parts) > 6 {
+		return c, fs.ErrorDirNotFound
+	}
+	c.depth = len(parts)
+	fields := c.fields()
+	for i, part := range parts {
+		*fields[i] = part
+	}
+	return c, nil
+}
+
+// fields returns pointers to the parts of c in path order
+func (c *coordinates) fields() []*string {
+	return []*string{&c.repo, &c.format, &c.namespace, &c.pkg, &c.version, &c.asset}
+}
+
+// split returns the coordinates of rootRelativePath relative to f.root
+//
+// The path is split before the parts are decoded so an encoded "/"
+// in a name can't become a separator.
+func (f *Fs) split(rootRelativePath string) (coordinates, error) {
+	c, err := parseCoordinates(path.Join(f.root, rootRelativePath))
+	if err != nil {
+		return c, err
+	}
+	for _, field := range c.fields()[:c.depth] {
+		*field = f.opt.Enc.FromStandardName(*field)
+	}
+	return c, nil
+}
+
+// namespaceParam returns the namespace for the API or nil if there isn't one
+func (c *coordinates) namespaceParam() *string {
+	if c.namespace == noNamespace {
+		return nil
+	}
+	return aws.String(c.namespace)
+}
+
+// validFormat returns true if format is one of the package formats
+func validFormat(format string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// retryErrorCodes is a slice of error codes that we will retry
+var retryErrorCodes = []int{
+	429, // Too Many Requests
+	500, // Internal Server Error
+	502, // Bad Gateway
+	503, // Service Unavailable
+	504, // Gateway Timeout
+}
+
+// shouldRetry returns a boolean as to whether this resp and err
+// deserve to be retried.  It returns the err as a convenience
+func shouldRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if fserrors.ContextError(ctx, &err) {
+		return false, err
+	}
+	if awsError, ok := err.(awserr.Error); ok {
+		if fserrors.ShouldRetry(awsError.OrigErr()) {
+			return true, err
+		}
+		if awsError.Code() == codeartifact.ErrCodeThrottlingException {
+			return true, err
+		}
+		if reqErr, ok := err.(awserr.RequestFailure); ok {
+			for _, e := range retryErrorCodes {
+				if reqErr.StatusCode() == e {
+					return true, err
+				}
+			}
+		}
+	}
+	return fserrors.ShouldRetry(err) || fserrors.ShouldRetryHTTP(resp, retryErrorCodes), err
+}
+
+// isNotFound returns true if err indicates the resource wasn't found
+func isNotFound(err error) bool {
+	awsError, ok := err.(awserr.Error)
+	return ok && awsError.Code() == codeartifact.ErrCodeResourceNotFoundException
+}
+
+// newConnection makes the CodeArtifact API client
+func newConnection(opt *Options, client *http.Client) (*codeartifact.CodeArtifact, error) {
+	awsConfig := aws.NewConfig().
+		WithMaxRetries(0). // Rely on rclone's retry logic
+		WithRegion(opt.Region).
+		WithHTTPClient(client)
+	if opt.Endpoint != "" {
+		awsConfig.WithEndpoint(opt.Endpoint)
+	}
+	switch {
+	case opt.AccessKeyID != "" || opt.SecretAccessKey != "":
+		if opt.AccessKeyID == "" {
+			return nil, errors.New("access_key_id not found")
+		}
+		if opt.SecretAccessKey == "" {
+			return nil, errors.New("secret_access_key not found")
+		}
+		awsConfig.WithCredentials(credentials.NewStaticCredentials(opt.AccessKeyID, opt.SecretAccessKey, opt.SessionToken))
+	case !opt.EnvAuth:
+		return nil, errors.New("set access_key_id and secret_access_key or env_auth")
+	}
+	awsSessionOpts := session.Options{
+		Config: *awsConfig,
+	}
+	if opt.EnvAuth {
+		// Enable loading config options from ~/.aws/config (selected by AWS_PROFILE env)
+		awsSessionOpts.SharedConfigState = session.SharedConfigEnable
+	}
+	ses, err := session.NewSessionWithOptions(awsSessionOpts)
+	if err != nil {
+		return nil, err
+	}
+	return codeartifact.New(ses), nil
+}
+
+// NewFs constructs an Fs from the path, repository/format/namespace/package/version
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	// Parse config into Options struct
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Domain == "" {
+		return nil, errors.New("domain not set")
+	}
+	if opt.Region == "" {
+		return nil, errors.New("region not set")
+	}
+	client := fshttp.NewClient(ctx)
+	c, err := newConnection(opt, client)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Fs{
+		name:      name,
+		root:      parsePath(root),
+		opt:       *opt,
+		c:         c,
+		srv:       rest.NewClient(client),
+		pacer:     fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
+		endpoints: map[string]string{},
+	}
+	f.features = (&fs.Features{
+		BucketBased:       true,
+		BucketBasedRootOK: true,
+	}).Fill(ctx, f)
+
+	if coords, err := f.split(""); err == nil && coords.depth == 6 {
+		// Check to see if the root is actually an existing asset
+		oldRoot := f.root
+		newRoot, leaf := path.Split(oldRoot)
+		f.root = parsePath(newRoot)
+		_, err := f.NewObject(ctx, leaf)
+		if err != nil {
+			// File doesn't exist so return old f
+			f.root = oldRoot
+			return f, nil
+		}
+		// return an error with an fs which points to the parent
+		return f, fs.ErrorIsFile
+	}
+	return f, nil
+}
+
+// domainOwner returns the domain owner for the API or nil if not set
+func (f *Fs) domainOwner() *string {
+	if f.opt.DomainOwner == "" {
+		return nil
+	}
+	return aws.String(f.opt.DomainOwner)
+}
+
+// authorizationToken returns a token for the repository endpoints,
+// getting a new one if it is about to expire
+func (f *Fs) authorizationToken(ctx context.Context) (string, error) {
+	f.tokenMu.Lock()
+	defer f.tokenMu.Unlock()
+	if f.token != "" && time.Until(f.tokenExpiry) > tokenExpiryWindow {
+		return f.token, nil
+	}
+	input := &codeartifact.GetAuthorizationTokenInput{
+		Domain:      aws.String(f.opt.Domain),
+		DomainOwner: f.domainOwner(),
+	}
+	var out *codeartifact.GetAuthorizationTokenOutput
+	err := f.pacer.Call(func() (bool, error) {
+		var err error
+		out, err = f.c.GetAuthorizationTokenWithContext(ctx, input)
+		return shouldRetry(ctx, nil, err)
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get authorization token")
+	}
+	f.token = aws.StringValue(out.AuthorizationToken)
+	f.tokenExpiry = aws.TimeValue(out.Expiration)
+	return f.token, nil
+}
+
+// repositoryEndpoint returns the URL of the endpoint of repo for format
+func (f *Fs) repositoryEndpoint(ctx context.Context, repo, format string) (string, error) {
+	f.tokenMu.Lock()
+	defer f.tokenMu.Unlock()
+	key := repo + "/" + format
+	if endpoint, ok := f.endpoints[key]; ok {
+		return endpoint, nil
+	}
+	input := &codeartifact.GetRepositoryEndpointInput{
+		Domain:      aws.String(f.opt.Domain),
+		DomainOwner: f.domainOwner(),
+		Repository:  aws.String(repo),
+		Format:      aws.String(format),
+	}
+	var out *codeartifact.GetRepositoryEndpointOutput
+	err := f.pacer.Call(func() (bool, error) {
+		var err error
+		out, err = f.c.GetRepositoryEndpointWithContext(ctx, input)
+		return shouldRetry(ctx, nil, err)
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get repository endpoint")
+	}
+	endpoint := aws.StringValue(out.RepositoryEndpoint)
+	if !strings.HasSuffix(endpoint, "/") {
+		endpoint += "/"
+	}
+	f.endpoints[key] = endpoint
+	return endpoint, nil
+}
+
+// describeRepository checks repo exists
+func (f *Fs) describeRepository(ctx context.Context, repo string) error {
+	input := &codeartifact.DescribeRepositoryInput{
+		Domain:      aws.String(f.opt.Domain),
+		DomainOwner: f.domainOwner(),
+		Repository:  aws.String(repo),
+	}
+	err := f.pacer.Call(func() (bool, error) {
+		_, err := f.c.DescribeRepositoryWithContext(ctx, input)
+		return shouldRetry(ctx, nil, err)
+	})
+	if isNotFound(err) {
+		return fs.ErrorDirNotFound
+	}
+	return err
+}
+
+// nextToken returns the NextToken parameter to read the page after
+// token, nil for the first page
+func nextToken(token string) *string {
+	if token == "" {
+		return nil
+	}
+	return aws.String(token)
+}
+
+// listRepositories lists the repositories of the domain as directories
+func (f *Fs) listRepositories(ctx context.Context) (entries fs.DirEntries, err error) {
+	input := &codeartifact.ListRepositoriesInDomainInput{
+		Domain:      aws.String(f.opt.Domain),
+		DomainOwner: f.domainOwner(),
+	}
+	err = artifact.Paginate(func(token string) (string, error) {
+		input.NextToken = nextToken(token)
+		var out *codeartifact.ListRepositoriesInDomainOutput
+		err := f.pacer.Call(func() (bool, error) {
+			var err error
+			out, err = f.c.ListRepositoriesInDomainWithContext(ctx, input)
+			return shouldRetry(ctx, nil, err)
+		})
+		if err != nil {
+			return "", err
+		}
+		for _, repo := range out.Repositories {
+			entries = append(entries, fs.NewDir(aws.StringValue(repo.Name), time.Time{}))
+		}
+		return aws.StringValue(out.NextToken), nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list repositories")
+	}
+	return entries, nil
+}
+
+// listPackages calls fn for each package of c.format in c.repo
+//
+// If c.namespace is set only the packages in it are listed.
+func (f *Fs) listPackages(ctx context.Context, c *coordinates, fn func(namespace, pkg string) error) (err error) {
+	input := &codeartifact.ListPackagesInput{
+		Domain:      aws.String(f.opt.Domain),
+		DomainOwner: f.domainOwner(),
+		Repository:  aws.String(c.repo),
+		Format:      aws.String(c.format),
+	}
+	if c.namespace != "" {
+		input.Namespace = c.namespaceParam()
+	}
+	return artifact.Paginate(func(token string) (string, error) {
+		input.NextToken = nextToken(token)
+		var out *codeartifact.ListPackagesOutput
+		err := f.pacer.Call(func() (bool, error) {
+			var err error
+			out, err = f.c.ListPackagesWithContext(ctx, input)
+			return shouldRetry(ctx, nil, err)
+		})
+		if err != nil {
+			if isNotFound(err) {
+				return "", fs.ErrorDirNotFound
+			}
+			return "", errors.Wrap(err, "failed to list packages")
+		}
+		for _, pkg := range out.Packages {
+			namespace := aws.StringValue(pkg.Namespace)
+			if namespace == "" {
+				namespace = noNamespace
+			}
+			// check the namespace matches exactly
+			if c.namespace != "" && namespace != c.namespace {
+				continue
+			}
+			err = fn(namespace, aws.StringValue(pkg.Package))
+			if err != nil {
+				return "", err
+			}
+		}
+		return aws.StringValue(out.NextToken), nil
+	})
+}
+
+// listVersions calls fn for each version of the package at c
+func (f *Fs) listVersions(ctx context.Context, c *coordinates, fn func(version string) error) (err error) {
+	input := &codeartifact.ListPackageVersionsInput{
+		Domain:      aws.String(f.opt.Domain),
+		DomainOwner: f.domainOwner(),
+		Repository:  aws.String(c.repo),
+		Format:      aws.String(c.format),
+		Namespace:   c.namespaceParam(),
+		Package:     aws.String(c.pkg),
+	}
+	return artifact.Paginate(func(token string) (string, error) {
+		input.NextToken = nextToken(token)
+		var out *codeartifact.ListPackageVersionsOutput
+		err := f.pacer.Call(func() (bool, error) {
+			var err error
+			out, err = f.c.ListPackageVersionsWithContext(ctx, input)
+			return shouldRetry(ctx, nil, err)
+		})
+		if err != nil {
+			if isNotFound(err) {
+				return "", fs.ErrorDirNotFound
+			}
+			return "", errors.Wrap(err, "failed to list package versions")
+		}
+		for _, version := range out.Versions {
+			err = fn(aws.StringValue(version.Version))
+			if err != nil {
+				return "", err
+			}
+		}
+		return aws.StringValue(out.NextToken), nil
+	})
+}
+
+// publishedTime returns the time the package version at c was published
+func (f *Fs) publishedTime(ctx context.Context, c *coordinates) (time.Time, error) {
+	input := &codeartifact.DescribePackageVersionInput{
+		Domain:         aws.String(f.opt.Domain),
+		DomainOwner:    f.domainOwner(),
+		Repository:     aws.String(c.repo),
+		Format:         aws.String(c.format),
+		Namespace:      c.namespaceParam(),
+		Package:        aws.String(c.pkg),
+		PackageVersion: aws.String(c.version),
+	}
+	var out *codeartifact.DescribePackageVersionOutput
+	err := f.pacer.Call(func() (bool, error) {
+		var err error
+		out, err = f.c.DescribePackageVersionWithContext(ctx, input)
+		return shouldRetry(ctx, nil, err)
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return time.Time{}, fs.ErrorDirNotFound
+		}
+		return time.Time{}, errors.Wrap(err, "failed to describe package version")
+	}
+	if out.PackageVersion == nil {
+		return time.Time{}, nil
+	}
+	return aws.TimeValue(out.PackageVersion.PublishedTime), nil
+}
+
+// listAssets calls fn with an Object for each asset of the package
+// version at c. The remotes of the objects are in dir.
+func (f *Fs) listAssets(ctx context.Context, c *coordinates, dir string, fn func(o *Object) error) error {
+	modTime, err := f.publishedTime(ctx, c)
+	if err != nil {
+		return err
+	}
+	input := &codeartifact.ListPackageVersionAssetsInput{
+		Domain:         aws.String(f.opt.Domain),
+		DomainOwner:    f.domainOwner(),
+		Repository:     aws.String(c.repo),
+		Format:         aws.String(c.format),
+		Namespace:      c.namespaceParam(),
+		Package:        aws.String(c.pkg),
+		PackageVersion: aws.String(c.version),
+	}
+	return artifact.Paginate(func(token string) (string, error) {
+		input.NextToken = nextToken(token)
+		var out *codeartifact.ListPackageVersionAssetsOutput
+		err := f.pacer.Call(func() (bool, error) {
+			var err error
+			out, err = f.c.ListPackageVersionAssetsWithContext(ctx, input)
+			return shouldRetry(ctx, nil, err)
+		})
+		if err != nil {
+			if isNotFound(err) {
+				return "", fs.ErrorDirNotFound
+			}
+			return "", errors.Wrap(err, "failed to list assets")
+		}
+		for _, asset := range out.Assets {
+			o := &Object{
+				fs:      f,
+				remote:  path.Join(dir, f.opt.Enc.ToStandardName(aws.StringValue(asset.Name))),
+				size:    aws.Int64Value(asset.Size),
+				modTime: modTime,
+				checksums: artifact.NewChecksums(
+					aws.StringValue(asset.Hashes[codeartifact.HashAlgorithmMd5]),
+					aws.StringValue(asset.Hashes[codeartifact.HashAlgorithmSha1]),
+				),
+			}
+			err = fn(o)
+			if err != nil {
+				return "", err
+			}
+		}
+		return aws.StringValue(out.NextToken), nil
+	})
+}
+
+// NewObject finds the Object at remote.  If it can't be found
+// it returns the error fs.ErrorObjectNotFound.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	c, err := f.split(remote)
+	if err != nil || c.depth != 6 || !validFormat(c.format) {
+		return nil, fs.ErrorObjectNotFound
+	}
+	var found *Object
+	err = f.listAssets(ctx, &c, path.Dir(remote), func(o *Object) error {
+		if path.Base(o.remote) == path.Base(remote) {
+			found = o
+		}
+		return nil
+	})
+	if err == fs.ErrorDirNotFound {
+		return nil, fs.ErrorObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fs.ErrorObjectNotFound
+	}
+	found.remote = remote
+	return found, nil
+}
+
+// List the objects and directories in dir into entries.  The
+// entries can be returned in any order but should be for a
+// complete directory.
+//
+// dir should be "" to list the root, and should not have
+// trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	c, err := f.split(dir)
+	if err != nil {
+		return nil, err
+	}
+	if c.depth >= 2 && !validFormat(c.format) {
+		return nil, fs.ErrorDirNotFound
+	}
+	seen := map[string]struct{}{}
+	addDir := func(name string) {
+		if _, ok := seen[name]; ok {
+			return
+		}
+		seen[name] = struct{}{}
+		entries = append(entries, fs.NewDir(path.Join(dir, f.opt.Enc.ToStandardName(name)), time.Time{}))
+	}
+	switch c.depth {
+	case 0:
+		return f.listRepositories(ctx)
+	case 1:
+		err = f.describeRepository(ctx, c.repo)
+		if err != nil {
+			return nil, err
+		}
+		for _, format := range formats {
+			addDir(format)
+		}
+	case 2, 3:
+		err = f.listPackages(ctx, &c, func(namespace, pkg string) error {
+			if c.depth == 2 {
+				addDir(namespace)
+			} else {
+				addDir(pkg)
+			}
+			return nil
+		})
+		if err == nil && c.depth == 3 && len(entries) == 0 {
+			err = fs.ErrorDirNotFound
+		}
+	case 4:
+		err = f.listVersions(ctx, &c, func(version string) error {
+			addDir(version)
+			return nil
+		})
+	case 5:
+		err = f.listAssets(ctx, &c, dir, func(o *Object) error {
+			entries = append(entries, o)
+			return nil
+		})
+	default:
+		err = fs.ErrorDirNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Put the object into the repository
+//
+// Copy the reader in to the new object which is returned
+//
+// The new object may have been created if an error is returned
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	o := &Object{
+		fs:     f,
+		remote: src.Remote(),
+	}
+	return o, o.Update(ctx, in, src, options...)
+}
+
+// Mkdir creates the directory if it doesn't exist
+//
+// Repositories must already exist. The other directories are made
+// from the package coordinates so there is nothing to do for them.
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	c, err := f.split(dir)
+	if err != nil {
+		return err
+	}
+	if c.depth != 1 {
+		return nil
+	}
+	err = f.describeRepository(ctx, c.repo)
+	if err == fs.ErrorDirNotFound {
+		return errors.Errorf("repository %q not found - create it in CodeArtifact first", c.repo)
+	}
+	return err
+}
+
+// Rmdir deletes the directory if it is empty
+//
+// Returns an error if it isn't empty
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	c, err := f.split(dir)
+	if err != nil {
+		return err
+	}
+	if c.depth == 0 {
+		return nil
+	}
+	if c.depth == 1 {
+		return errors.Errorf("can't remove repository %q", c.repo)
+	}
+	entries, err := f.List(ctx, dir)
+	if err == fs.ErrorDirNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) != 0 {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	return nil
+}
+
+// deleteVersions deletes versions of the package at c
+func (f *Fs) deleteVersions(ctx context.Context, c *coordinates, versions []string) error {
+	for len(versions) > 0 {
+		n := len(versions)
+		if n > maxDeleteVersions {
+			n = maxDeleteVersions
+		}
+		input := &codeartifact.DeletePackageVersionsInput{
+			Domain:      aws.String(f.opt.Domain),
+			DomainOwner: f.domainOwner(),
+			Repository:  aws.String(c.repo),
+			Format:      aws.String(c.format),
+			Namespace:   c.namespaceParam(),
+			Package:     aws.String(c.pkg),
+			Versions:    aws.StringSlice(versions[:n]),
+		}
+		var out *codeartifact.DeletePackageVersionsOutput
+		err := f.pacer.Call(func() (bool, error) {
+			var err error
+			out, err = f.c.DeletePackageVersionsWithContext(ctx, input)
+			return shouldRetry(ctx, nil, err)
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to delete package versions")
+		}
+		if len(out.FailedVersions) > 0 {
+			var failed []string
+			for version, e := range out.FailedVersions {
+				failed = append(failed, fmt.Sprintf("%s: %s", version, aws.StringValue(e.ErrorMessage)))
+			}
+			return errors.Errorf("failed to delete package versions: %s", strings.Join(failed, ", "))
+		}
+		versions = versions[n:]
+	}
+	return nil
+}
+
+// Purge deletes the package version or all the versions of the
+// package in dir
+func (f *Fs) Purge(ctx context.Context, dir string) error {
+	c, err := f.split(dir)
+	if err != nil {
+		return err
+	}
+	if !validFormat(c.format) {
+		return fs.ErrorDirNotFound
+	}
+	switch c.depth {
+	case 4:
+		var versions []string
+		err = f.listVersions(ctx, &c, func(version string) error {
+			versions = append(versions, version)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return f.deleteVersions(ctx, &c, versions)
+	case 5:
+		return f.deleteVersions(ctx, &c, []string{c.version})
+	}
+	return fs.ErrorCantPurge
+}
+
+// Precision of the ModTimes in this Fs
+func (f *Fs) Precision() time.Duration {
+	return fs.ModTimeNotSupported
+}
+
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() hash.Set {
+	return artifact.ChecksumTypes()
+}
+
+// ------------------------------------------------------------
+
+// Fs returns the parent Fs
+func (o *Object) Fs() fs.Info {
+	return o.fs
+}
+
+// Return a string version
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// Remote returns the remote path
+func (o *Object) Remote() string {
+	return o.remote
+}
+
+// Hash returns the MD5 or SHA-1 of an object returning a lowercase hex string
+func (o *Object) Hash(ctx context.Context, t hash.Type) (string, error) {
+	return o.checksums.Get(t)
+}
+
+// Size returns the size of an object in bytes
+func (o *Object) Size() int64 {
+	return o.size
+}
+
+// ModTime returns the modification time of the object
+//
+// This is the time the package version was published
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	return o.modTime
+}
+
+// SetModTime sets the modification time of the object
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	return fs.ErrorCantSetModTime
+}
+
+// Storable returns a boolean showing whether this object storable
+func (o *Object) Storable() bool {
+	return true
+}
+
+// Open an object for read
+//
+// The API doesn't support ranges so the start of the asset is
+// skipped if needed.
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
+	c, err := o.fs.split(o.remote)
+	if err != nil {
+		return nil, err
+	}
+	var offset, limit int64 = 0, -1
+	for _, option := range options {
+		switch x := option.(type) {
+		case *fs.SeekOption:
+			offset = x.Offset
+		case *fs.RangeOption:
+			offset, limit = x.Decode(o.size)
+		default:
+			if option.Mandatory() {
+				fs.Logf(o, "Unsupported mandatory option: %v", option)
+			}
+		}
+	}
+	input := &codeartifact.GetPackageVersionAssetInput{
+		Domain:         aws.String(o.fs.opt.Domain),
+		DomainOwner:    o.fs.domainOwner(),
+		Repository:     aws.String(c.repo),
+		Format:         aws.String(c.format),
+		Namespace:      c.namespaceParam(),
+		Package:        aws.String(c.pkg),
+		PackageVersion: aws.String(c.version),
+		Asset:          aws.String(c.asset),
+	}
+	var out *codeartifact.GetPackageVersionAssetOutput
+	err = o.fs.pacer.Call(func() (bool, error) {
+		out, err = o.fs.c.GetPackageVersionAssetWithContext(ctx, input)
+		return shouldRetry(ctx, nil, err)
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fs.ErrorObjectNotFound
+		}
+		return nil, err
+	}
+	if offset > 0 {
+		_, err = io.CopyN(ioutil.Discard, out.Asset, offset)
+		if err != nil {
+			_ = out.Asset.Close()
+			return nil, errors.Wrap(err, "failed to skip to offset")
+		}
+	}
+	return readers.NewLimitedReadCloser(out.Asset, limit), nil
+}
+
+// mavenPath returns the path of the asset at c in a maven2
+// repository
+func mavenPath(c *coordinates) (string, error) {
+	if c.format != codeartifact.PackageFormatMaven {
+		return "", errors.Errorf("can't upload %s packages - only maven packages can be uploaded", c.format)
+	}
+	if c.namespace == noNamespace {
+		return "", errors.New("maven packages need a namespace (the groupId)")
+	}
+	m := artifact.Maven{
+		GroupID:    c.namespace,
+		ArtifactID: c.pkg,
+		Version:    c.version,
+	}
+	return path.Join(m.Dir(), c.asset), nil
+}
+
+// Update the object with the contents of the io.Reader, modTime and size
+//
+// Assets are uploaded to the maven repository endpoint using an
+// authorization token.
+//
+// The new object may have been created if an error is returned
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (err error) {
+	c, err := o.fs.split(o.remote)
+	if err != nil {
+		return err
+	}
+	if c.depth != 6 {
+		return errors.New("assets must be uploaded to repository/format/namespace/package/version")
+	}
+	assetPath, err := mavenPath(&c)
+	if err != nil {
+		return err
+	}
+	endpoint, err := o.fs.repositoryEndpoint(ctx, c.repo, c.format)
+	if err != nil {
+		return err
+	}
+	token, err := o.fs.authorizationToken(ctx)
+	if err != nil {
+		return err
+	}
+	size := src.Size()
+	opts := rest.Opts{
+		Method:     "PUT",
+		RootURL:    endpoint + rest.URLPathEscape(assetPath),
+		Body:       in,
+		UserName:   "aws",
+		Password:   token,
+		Options:    options,
+		NoResponse: true,
+	}
+	if size >= 0 {
+		opts.ContentLength = &size
+	}
+	err = o.fs.pacer.CallNoRetry(func() (bool, error) {
+		resp, err := o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "upload failed")
+	}
+	newObj, err := o.fs.NewObject(ctx, o.remote)
+	if err != nil {
+		return errors.Wrap(err, "failed to read uploaded asset")
+	}
+	*o = *newObj.(*Object)
+	return nil
+}
+
+// Remove an object
+//
+// CodeArtifact can only delete whole package versions so this
+// returns an error.
+func (o *Object) Remove(ctx context.Context) error {
+	return errors.New("can't remove single assets - purge the version directory instead")
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs     = &Fs{}
+	_ fs.Purger = &Fs{}
+	_ fs.Object = &Object{}
+)
@@ -0,0 +1,273 @@
+package codeartifact
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCoordinates(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want coordinates
+	}{
+		{"", coordinates{}},
+		{"repo", coordinates{depth: 1, repo: "repo"}},
+		{"/repo/maven/", coordinates{depth: 2, repo: "repo", format: "maven"}},
+		{"repo/npm/-/left-pad/1.3.0/left-pad-1.3.0.tgz", coordinates{
+			depth:     6,
+			repo:      "repo",
+			format:    "npm",
+			namespace: "-",
+			pkg:       "left-pad",
+			version:   "1.3.0",
+			asset:     "left-pad-1.3.0.tgz",
+		}},
+	} {
+		got, err := parseCoordinates(test.in)
+		require.NoError(t, err, test.in)
+		assert.Equal(t, test.want, got, test.in)
+	}
+	_, err := parseCoordinates("repo/maven/org.example/app/1.0/app-1.0.jar/potato")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+}
+
+func TestSplit(t *testing.T) {
+	f := &Fs{
+		root: "repo/npm",
+		opt: Options{
+			Enc: encoder.Display | encoder.EncodeBackSlash | encoder.EncodeInvalidUtf8,
+		},
+	}
+	got, err := f.split("@scope／name/left-pad／x/1.0")
+	require.NoError(t, err)
+	assert.Equal(t, coordinates{
+		depth:     5,
+		repo:      "repo",
+		format:    "npm",
+		namespace: "@scope/name",
+		pkg:       "left-pad/x",
+		version:   "1.0",
+	}, got)
+}
+
+func TestMavenPath(t *testing.T) {
+	c, err := parseCoordinates("repo/maven/org.example/app/1.0/app-1.0.jar")
+	require.NoError(t, err)
+	got, err := mavenPath(&c)
+	require.NoError(t, err)
+	assert.Equal(t, "org/example/app/1.0/app-1.0.jar", got)
+
+	c.namespace = noNamespace
+	_, err = mavenPath(&c)
+	assert.Error(t, err)
+
+	c, err = parseCoordinates("repo/npm/-/left-pad/1.3.0/left-pad-1.3.0.tgz")
+	require.NoError(t, err)
+	_, err = mavenPath(&c)
+	assert.Error(t, err)
+	assert.Nil(t, c.namespaceParam())
+}
+
+// testAsset is an asset stored by the test server
+type testAsset struct {
+	name    string
+	content string
+}
+
+// prepare starts a test server standing in for the CodeArtifact API
+// and a maven repository endpoint and returns an Fs using it
+func prepare(t *testing.T) (fs.Fs, func()) {
+	var (
+		ts       *httptest.Server
+		mu       sync.Mutex
+		assets   = []testAsset{{"app-1.0.jar", "jar"}}
+		notFound = func(w http.ResponseWriter) {
+			w.Header().Set("X-Amzn-Errortype", "ResourceNotFoundException")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"message":"not found"}`)
+		}
+	)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		query := r.URL.Query()
+		if strings.HasPrefix(r.URL.Path, "/v1/") {
+			assert.Equal(t, "domain", query.Get("domain"), r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/domain/repositories":
+			_, _ = fmt.Fprint(w, `{"repositories":[{"name":"repo"}]}`)
+		case "GET /v1/repository":
+			if query.Get("repository") != "repo" {
+				notFound(w)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"repository":{"name":"repo"}}`)
+		case "POST /v1/packages":
+			_, _ = fmt.Fprint(w, `{"packages":[{"format":"maven","namespace":"org.example","package":"app"},{"format":"maven","namespace":"org.example","package":"lib"}]}`)
+		case "POST /v1/package/versions":
+			_, _ = fmt.Fprint(w, `{"versions":[{"version":"1.0","status":"Published"}]}`)
+		case "GET /v1/package/version":
+			if query.Get("version") != "1.0" {
+				notFound(w)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"packageVersion":{"format":"maven","namespace":"org.example","packageName":"app","version":"1.0","publishedTime":1612325106}}`)
+		case "POST /v1/package/version/assets":
+			if query.Get("version") != "1.0" {
+				notFound(w)
+				return
+			}
+			// return the assets one per page to check pagination
+			i := 0
+			if token := query.Get("next-token"); token != "" {
+				_, _ = fmt.Sscan(token, &i)
+			}
+			asset := assets[i]
+			next := ""
+			if i+1 < len(assets) {
+				next = fmt.Sprint(i + 1)
+			}
+			// the hashes are upper case to check they are normalised
+			_, _ = fmt.Fprintf(w, `{"assets":[{"name":%q,"size":%d,"hashes":{"MD5":%q,"SHA-1":%q}}],"nextToken":%q}`,
+				asset.name, len(asset.content),
+				strings.ToUpper(hashOf(t, hash.MD5, asset.content)),
+				strings.ToUpper(hashOf(t, hash.SHA1, asset.content)),
+				next)
+		case "GET /v1/repository/endpoint":
+			assert.Equal(t, "maven", query.Get("format"))
+			_, _ = fmt.Fprintf(w, `{"repositoryEndpoint":"%s/maven/repo"}`, ts.URL)
+		case "POST /v1/authorization-token":
+			_, _ = fmt.Fprintf(w, `{"authorizationToken":"TOKEN","expiration":%d}`, time.Now().Add(time.Hour).Unix())
+		default:
+			if r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/maven/repo/org/example/app/1.0/") {
+				user, pass, _ := r.BasicAuth()
+				assert.Equal(t, "aws", user)
+				assert.Equal(t, "TOKEN", pass)
+				content, err := ioutil.ReadAll(r.Body)
+				assert.NoError(t, err)
+				name := strings.TrimPrefix(r.URL.Path, "/maven/repo/org/example/app/1.0/")
+				assets = append(assets, testAsset{name, string(content)})
+				return
+			}
+			notFound(w)
+		}
+	})
+	ts = httptest.NewServer(handler)
+	f, err := NewFs(context.Background(), "TestCodeArtifact", "", configmap.Simple{
+		"domain":            "domain",
+		"region":            "us-east-1",
+		"access_key_id":     "ACCESS",
+		"secret_access_key": "SECRET",
+		"endpoint":          ts.URL,
+	})
+	require.NoError(t, err)
+	return f, ts.Close
+}
+
+// hashOf returns the hash of type ht of content
+func hashOf(t *testing.T, ht hash.Type, content string) string {
+	sums, err := hash.StreamTypes(strings.NewReader(content), hash.NewHashSet(ht))
+	assert.NoError(t, err)
+	return sums[ht]
+}
+
+// listNames lists dir returning the sorted remotes
+func listNames(t *testing.T, f fs.Fs, dir string) []string {
+	entries, err := f.List(context.Background(), dir)
+	require.NoError(t, err, dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Remote())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestList(t *testing.T) {
+	f, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	assert.Equal(t, []string{"repo"}, listNames(t, f, ""))
+	assert.Equal(t, []string{"repo/maven", "repo/npm", "repo/nuget", "repo/pypi"}, listNames(t, f, "repo"))
+	assert.Equal(t, []string{"repo/maven/org.example"}, listNames(t, f, "repo/maven"))
+	assert.Equal(t, []string{"repo/maven/org.example/app", "repo/maven/org.example/lib"}, listNames(t, f, "repo/maven/org.example"))
+	assert.Equal(t, []string{"repo/maven/org.example/app/1.0"}, listNames(t, f, "repo/maven/org.example/app"))
+	assert.Equal(t, []string{"repo/maven/org.example/app/1.0/app-1.0.jar"}, listNames(t, f, "repo/maven/org.example/app/1.0"))
+
+	_, err := f.List(ctx, "potato")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+	_, err = f.List(ctx, "repo/potato")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+	_, err = f.List(ctx, "repo/maven/org.example/app/2.0")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+}
+
+func TestObject(t *testing.T) {
+	f, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	o, err := f.NewObject(ctx, "repo/maven/org.example/app/1.0/app-1.0.jar")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), o.Size())
+	assert.True(t, time.Unix(1612325106, 0).Equal(o.ModTime(ctx)), o.ModTime(ctx))
+	md5, err := o.Hash(ctx, hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, hashOf(t, hash.MD5, "jar"), md5)
+
+	_, err = f.NewObject(ctx, "repo/maven/org.example/app/1.0/app-1.0.war")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+	_, err = f.NewObject(ctx, "repo/maven/org.example/app/2.0/app-2.0.jar")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+}
+
+func TestPut(t *testing.T) {
+	f, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	const content = "<project/>"
+	remote := "repo/maven/org.example/app/1.0/app-1.0.pom"
+	src := object.NewStaticObjectInfo(remote, time.Now(), int64(len(content)), true, nil, nil)
+	o, err := f.Put(ctx, bytes.NewBufferString(content), src)
+	require.NoError(t, err)
+	assert.Equal(t, remote, o.Remote())
+	assert.Equal(t, int64(len(content)), o.Size())
+	sha1, err := o.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, hashOf(t, hash.SHA1, content), sha1)
+
+	// the uploaded asset is found on the second page of the listing
+	assert.Equal(t, []string{
+		"repo/maven/org.example/app/1.0/app-1.0.jar",
+		"repo/maven/org.example/app/1.0/app-1.0.pom",
+	}, listNames(t, f, "repo/maven/org.example/app/1.0"))
+
+	// only maven assets at the full depth can be uploaded
+	src = object.NewStaticObjectInfo("repo/npm/-/left-pad/1.3.0/left-pad-1.3.0.tgz", time.Now(), 1, true, nil, nil)
+	_, err = f.Put(ctx, bytes.NewBufferString("x"), src)
+	assert.Error(t, err)
+	src = object.NewStaticObjectInfo("repo/maven/file.txt", time.Now(), 1, true, nil, nil)
+	_, err = f.Put(ctx, bytes.NewBufferString("x"), src)
+	assert.Error(t, err)
+}
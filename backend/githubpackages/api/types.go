@@ -0,0 +1,89 @@
+// Package api has type definitions for GitHub Packages
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// Error is returned by the GitHub API when something goes wrong
+type Error struct {
+	StatusCode       int    `json:"-"`
+	Message          string `json:"message"`
+	DocumentationURL string `json:"documentation_url"`
+}
+
+// Error returns a string for the error and satisfies the error interface
+func (e *Error) Error() string {
+	out := fmt.Sprintf("github error %d", e.StatusCode)
+	if e.Message != "" {
+		out += ": " + e.Message
+	}
+	return out
+}
+
+// User describes a GitHub user
+type User struct {
+	Login string `json:"login"`
+}
+
+// Repository is the repository a package belongs to
+type Repository struct {
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+}
+
+// Package describes a package
+type Package struct {
+	ID           int64       `json:"id"`
+	Name         string      `json:"name"`
+	PackageType  string      `json:"package_type"`
+	VersionCount int         `json:"version_count"`
+	Visibility   string      `json:"visibility"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+	Repository   *Repository `json:"repository"`
+}
+
+// ContainerMetadata is the metadata of a container package version
+type ContainerMetadata struct {
+	Tags []string `json:"tags"`
+}
+
+// VersionMetadata is the metadata of a package version
+type VersionMetadata struct {
+	PackageType string             `json:"package_type"`
+	Container   *ContainerMetadata `json:"container"`
+}
+
+// Version describes a version of a package
+type Version struct {
+	ID        int64            `json:"id"`
+	Name      string           `json:"name"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	Metadata  *VersionMetadata `json:"metadata"`
+}
+
+// NpmDist describes the tarball of an npm package version
+type NpmDist struct {
+	Tarball string `json:"tarball"`
+	Shasum  string `json:"shasum"`
+}
+
+// NpmVersion is a version in an npm packument
+type NpmVersion struct {
+	Version string  `json:"version"`
+	Dist    NpmDist `json:"dist"`
+}
+
+// NpmPackument is the npm registry description of a package
+type NpmPackument struct {
+	Name     string                `json:"name"`
+	Versions map[string]NpmVersion `json:"versions"`
+}
+
+// RegistryToken is returned by the container registry token service
+type RegistryToken struct {
+	Token string `json:"token"`
+}
@@ -0,0 +1,900 @@
+// Package githubpackages provides an interface to the packages
+// published on GitHub Packages.
+package githubpackages
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/githubpackages/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/artifact"
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+const (
+	minSleep      = 10 * time.Millisecond
+	maxSleep      = 2 * time.Second
+	decayConstant = 2   // bigger for slower decay, exponential
+	perPage       = 100 // maximum page size the API allows
+
+	typeContainer = "container"
+	typeMaven     = "maven"
+	typeNpm       = "npm"
+
+	manifestName = "manifest.json"
+)
+
+// The endpoints of the API and the registries - these are variables
+// so the tests can point them at a local server
+var (
+	apiURL       = "https://api.github.com"
+	npmURL       = "https://npm.pkg.github.com"
+	mavenURL     = "https://maven.pkg.github.com"
+	containerURL = "https://ghcr.io"
+)
+
+// packageTypes are the package types shown as the top level
+// directories
+var packageTypes = []string{typeContainer, typeMaven, typeNpm}
+
+// manifestAccept is the Accept header used to fetch container manifests
+var manifestAccept = strings.Join([]string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}, ", ")
+
+// errReadOnly is returned for operations which would upload or change files
+var errReadOnly = errors.New("uploading to GitHub Packages isn't supported - publish packages with the package manager instead")
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "githubpackages",
+		Description: "GitHub Packages",
+		NewFs:       NewFs,
+		Options: []fs.Option{{
+			Name: "token",
+			Help: `Personal access token.
+
+This needs the read:packages scope, and delete:packages to remove
+packages.`,
+			Required: true,
+		}, {
+			Name: "owner",
+			Help: `User or organization owning the packages.
+
+Leave blank to use the user the token belongs to.`,
+		}, {
+			Name:    "owner_type",
+			Help:    "Type of the owner.",
+			Default: "user",
+			Examples: []fs.OptionExample{{
+				Value: "user",
+				Help:  "The owner is a user",
+			}, {
+				Value: "org",
+				Help:  "The owner is an organization",
+			}},
+		}, {
+			Name:     config.ConfigEncoding,
+			Help:     config.ConfigEncodingHelp,
+			Advanced: true,
+			Default: (encoder.Display |
+				encoder.EncodeInvalidUtf8),
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	Token     string               `config:"token"`
+	Owner     string               `config:"owner"`
+	OwnerType string               `config:"owner_type"`
+	Enc       encoder.MultiEncoder `config:"encoding"`
+}
+
+// Fs represents the packages of a GitHub user or organization
+type Fs struct {
+	name     string       // name of this remote
+	root     string       // the path we are working on if any
+	opt      Options      // parsed config options
+	features *fs.Features // optional features
+	srv      *rest.Client // the connection to the GitHub API
+	reg      *rest.Client // the connection to the package registries
+	pacer    *fs.Pacer    // pacer for API calls
+	owner    string       // login of the owner of the packages
+	scope    string       // API path of the owner of the packages
+}
+
+// Object describes a file in a package version
+type Object struct {
+	fs        *Fs       // what this object is part of
+	remote    string    // The remote path
+	pkgType   string    // type of the package
+	pkgName   string    // name of the package
+	versionID int64     // ID of the package version
+	url       string    // URL to download the file from
+	size      int64     // size of the object or -1 if unknown
+	modTime   time.Time // update time of the package version
+	sha1      string    // SHA-1 of the file if known
+}
+
+// location is a path in the packages split into its parts
+//
+// Which of typ, name, version and file are set is given by depth.
+type location struct {
+	depth   int    // number of parts set below
+	typ     string // package type
+	name    string // package name
+	version string // package version
+	file    string // file name
+}
+
+// ------------------------------------------------------------
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	return fmt.Sprintf("GitHub Packages of %s path %q", f.owner, f.root)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// locate splits rootRelativePath into its parts
+func (f *Fs) locate(rootRelativePath string) (loc location, err error) {
+	absPath := path.Join(f.root, rootRelativePath)
+	var parts []string
+	if absPath != "" {
+		parts = strings.Split(absPath, "/")
+	}
+	if len(parts) > 4 {
+		return loc, errors.Errorf("path %q is too deep - paths are type/package/version/file", absPath)
+	}
+	for i := range parts {
+		parts[i] = f.opt.Enc.FromStandardName(parts[i])
+	}
+	loc.depth = len(parts)
+	switch loc.depth {
+	case 4:
+		loc.file = parts[3]
+		fallthrough
+	case 3:
+		loc.version = parts[2]
+		fallthrough
+	case 2:
+		loc.name = parts[1]
+		fallthrough
+	case 1:
+		loc.typ = parts[0]
+	}
+	return loc, nil
+}
+
+// knownType returns true if typ is one of the package types shown
+func knownType(typ string) bool {
+	for _, t := range packageTypes {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// packagePath returns the API path for the package
+func (f *Fs) packagePath(typ, name string) string {
+	return f.scope + "/packages/" + typ + "/" + url.PathEscape(name)
+}
+
+// retryErrorCodes is a slice of error codes that we will retry
+var retryErrorCodes = []int{
+	429, // Too Many Requests.
+	500, // Internal Server Error
+	502, // Bad Gateway
+	503, // Service Unavailable
+	504, // Gateway Timeout
+	509, // Bandwidth Limit Exceeded
+}
+
+// shouldRetry returns a boolean as to whether this resp and err
+// deserve to be retried.  It returns the err as a convenience
+func shouldRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if fserrors.ContextError(ctx, &err) {
+		return false, err
+	}
+	return fserrors.ShouldRetry(err) || fserrors.ShouldRetryHTTP(resp, retryErrorCodes), err
+}
+
+// errorHandler parses a non 2xx error response into an error
+func errorHandler(resp *http.Response) error {
+	errResponse := new(api.Error)
+	err := rest.DecodeJSON(resp, &errResponse)
+	if err != nil {
+		fs.Debugf(nil, "Couldn't decode error response: %v", err)
+	}
+	errResponse.StatusCode = resp.StatusCode
+	if errResponse.Message == "" {
+		errResponse.Message = resp.Status
+	}
+	return errResponse
+}
+
+// isNotFound returns true if resp indicates the item wasn't found
+func isNotFound(resp *http.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusNotFound
+}
+
+// NewFs constructs an Fs from the path
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	// Parse config into Options struct
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Token == "" {
+		return nil, errors.New("token must be set")
+	}
+
+	client := fshttp.NewClient(ctx)
+	f := &Fs{
+		name:  name,
+		root:  strings.Trim(root, "/"),
+		opt:   *opt,
+		srv:   rest.NewClient(client).SetRoot(apiURL),
+		reg:   rest.NewClient(client),
+		pacer: fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
+		owner: opt.Owner,
+	}
+	f.features = (&fs.Features{}).Fill(ctx, f)
+	f.srv.SetErrorHandler(errorHandler)
+	f.srv.SetHeader("Authorization", "Bearer "+opt.Token)
+	f.srv.SetHeader("Accept", "application/vnd.github.v3+json")
+
+	switch {
+	case opt.Owner == "":
+		opts := rest.Opts{
+			Method: "GET",
+			Path:   "/user",
+		}
+		var user api.User
+		err = f.pacer.Call(func() (bool, error) {
+			resp, err := f.srv.CallJSON(ctx, &opts, nil, &user)
+			return shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read user of token")
+		}
+		f.owner = user.Login
+		f.scope = "/user"
+	case opt.OwnerType == "user":
+		f.scope = "/users/" + url.PathEscape(opt.Owner)
+	case opt.OwnerType == "org":
+		f.scope = "/orgs/" + url.PathEscape(opt.Owner)
+	default:
+		return nil, errors.Errorf("unknown owner_type %q", opt.OwnerType)
+	}
+
+	// Check to see if the root is actually an existing file
+	loc, err := f.locate("")
+	if err != nil {
+		return nil, err
+	}
+	if loc.depth == 4 {
+		oldRoot := f.root
+		newRoot, leaf := path.Split(oldRoot)
+		f.root = strings.Trim(newRoot, "/")
+		_, err := f.NewObject(ctx, leaf)
+		if err != nil {
+			// File doesn't exist so return old f
+			f.root = oldRoot
+			return f, nil
+		}
+		// return an error with an fs which points to the parent
+		return f, fs.ErrorIsFile
+	}
+	return f, nil
+}
+
+// paginate calls the API with opts for every page of results, calling
+// fn to decode each page
+//
+// The following pages are read from the Link header.
+func (f *Fs) paginate(ctx context.Context, opts *rest.Opts, fn func(resp *http.Response) error) (resp *http.Response, err error) {
+	if opts.Parameters == nil {
+		opts.Parameters = url.Values{}
+	}
+	opts.Parameters.Set("per_page", strconv.Itoa(perPage))
+	err = artifact.Paginate(func(next string) (string, error) {
+		if next != "" {
+			// the next link has all the parameters in already
+			opts.RootURL, opts.Path, opts.Parameters = next, "", nil
+		}
+		err = f.pacer.Call(func() (bool, error) {
+			resp, err = f.srv.Call(ctx, opts)
+			return shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return "", err
+		}
+		return artifact.NextLink(resp.Header), fn(resp)
+	})
+	return resp, err
+}
+
+// listPackages lists the packages of type typ
+func (f *Fs) listPackages(ctx context.Context, typ string) (packages []api.Package, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   f.scope + "/packages",
+		Parameters: url.Values{
+			"package_type": {typ},
+		},
+	}
+	_, err = f.paginate(ctx, &opts, func(resp *http.Response) error {
+		var result []api.Package
+		err := rest.DecodeJSON(resp, &result)
+		if err != nil {
+			return err
+		}
+		packages = append(packages, result...)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list packages")
+	}
+	return packages, nil
+}
+
+// getPackage reads the package name of type typ
+func (f *Fs) getPackage(ctx context.Context, typ, name string) (pkg *api.Package, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   f.packagePath(typ, name),
+	}
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &pkg)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if isNotFound(resp) {
+			return nil, fs.ErrorDirNotFound
+		}
+		return nil, errors.Wrap(err, "failed to read package")
+	}
+	return pkg, nil
+}
+
+// listVersions lists the versions of the package name of type typ
+func (f *Fs) listVersions(ctx context.Context, typ, name string) (versions []api.Version, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   f.packagePath(typ, name) + "/versions",
+	}
+	resp, err := f.paginate(ctx, &opts, func(resp *http.Response) error {
+		var result []api.Version
+		err := rest.DecodeJSON(resp, &result)
+		if err != nil {
+			return err
+		}
+		versions = append(versions, result...)
+		return nil
+	})
+	if err != nil {
+		if isNotFound(resp) {
+			return nil, fs.ErrorDirNotFound
+		}
+		return nil, errors.Wrap(err, "failed to list versions")
+	}
+	return versions, nil
+}
+
+// findVersion finds the version at loc
+//
+// The API can only read versions by ID so this lists them all.
+func (f *Fs) findVersion(ctx context.Context, loc location) (*api.Version, error) {
+	versions, err := f.listVersions(ctx, loc.typ, loc.name)
+	if err != nil {
+		return nil, err
+	}
+	for i := range versions {
+		if versions[i].Name == loc.version {
+			return &versions[i], nil
+		}
+	}
+	return nil, fs.ErrorDirNotFound
+}
+
+// registryAuth sets the credentials for the registry of the package
+// type typ in opts
+func (f *Fs) registryAuth(typ string, opts *rest.Opts) {
+	if typ == typeMaven {
+		opts.UserName = f.owner
+		opts.Password = f.opt.Token
+		return
+	}
+	if opts.ExtraHeaders == nil {
+		opts.ExtraHeaders = map[string]string{}
+	}
+	opts.ExtraHeaders["Authorization"] = "Bearer " + f.opt.Token
+}
+
+// containerToken gets a token to pull the container image name from
+// the container registry
+func (f *Fs) containerToken(ctx context.Context, name string) (string, error) {
+	image := strings.ToLower(f.owner + "/" + name)
+	opts := rest.Opts{
+		Method:   "GET",
+		RootURL:  containerURL + "/token",
+		UserName: f.owner,
+		Password: f.opt.Token,
+		Parameters: url.Values{
+			"scope":   {"repository:" + image + ":pull"},
+			"service": {"ghcr.io"},
+		},
+	}
+	var token api.RegistryToken
+	err := f.pacer.Call(func() (bool, error) {
+		resp, err := f.reg.CallJSON(ctx, &opts, nil, &token)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get container registry token")
+	}
+	return token.Token, nil
+}
+
+// npmFiles returns the tarball of the npm package version
+func (f *Fs) npmFiles(ctx context.Context, loc location, o *Object) (objects []*Object, err error) {
+	opts := rest.Opts{
+		Method:  "GET",
+		RootURL: npmURL + "/@" + strings.ToLower(f.owner) + "%2F" + url.PathEscape(loc.name),
+	}
+	f.registryAuth(typeNpm, &opts)
+	var packument api.NpmPackument
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err := f.reg.CallJSON(ctx, &opts, nil, &packument)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read npm package")
+	}
+	version, ok := packument.Versions[loc.version]
+	if !ok || version.Dist.Tarball == "" {
+		return nil, nil
+	}
+	o.url = version.Dist.Tarball
+	o.sha1 = version.Dist.Shasum
+	return []*Object{o}, nil
+}
+
+// containerFiles returns the manifest of the container image version
+func (f *Fs) containerFiles(loc location, o *Object) []*Object {
+	image := strings.ToLower(f.owner + "/" + loc.name)
+	o.url = containerURL + "/v2/" + rest.URLPathEscape(image) + "/manifests/" + url.PathEscape(loc.version)
+	return []*Object{o}
+}
+
+// mavenFiles returns the POM and the JAR of the maven package version
+//
+// The package name is groupId.artifactId and the files are found by
+// probing the registry as the API doesn't list them.
+func (f *Fs) mavenFiles(ctx context.Context, loc location, o *Object) (objects []*Object, err error) {
+	pkg, err := f.getPackage(ctx, loc.typ, loc.name)
+	if err != nil {
+		return nil, err
+	}
+	if pkg.Repository == nil {
+		return nil, errors.Errorf("maven package %q isn't linked to a repository", loc.name)
+	}
+	i := strings.LastIndex(loc.name, ".")
+	if i < 0 {
+		return nil, errors.Errorf("can't find the artifact in maven package name %q", loc.name)
+	}
+	m := artifact.Maven{
+		GroupID:    loc.name[:i],
+		ArtifactID: loc.name[i+1:],
+		Version:    loc.version,
+	}
+	dirURL := mavenURL + "/" + rest.URLPathEscape(pkg.Repository.FullName) + "/" + rest.URLPathEscape(m.Dir()) + "/"
+	for _, extension := range []string{"pom", "jar"} {
+		m.Extension = extension
+		fileName := m.FileName()
+		opts := rest.Opts{
+			Method:       "HEAD",
+			RootURL:      dirURL + url.PathEscape(fileName),
+			IgnoreStatus: true,
+			NoResponse:   true,
+		}
+		f.registryAuth(typeMaven, &opts)
+		var resp *http.Response
+		err = f.pacer.Call(func() (bool, error) {
+			resp, err = f.reg.Call(ctx, &opts)
+			return shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", fileName)
+		}
+		if resp.StatusCode != http.StatusOK {
+			continue
+		}
+		file := *o
+		file.remote = path.Join(o.remote, f.opt.Enc.ToStandardName(fileName))
+		file.url = opts.RootURL
+		file.size = resp.ContentLength
+		objects = append(objects, &file)
+	}
+	return objects, nil
+}
+
+// listVersionFiles lists the files of version which is at loc
+//
+// dir is the remote path of the version directory.
+func (f *Fs) listVersionFiles(ctx context.Context, dir string, loc location, version *api.Version) (objects []*Object, err error) {
+	o := &Object{
+		fs:        f,
+		pkgType:   loc.typ,
+		pkgName:   loc.name,
+		versionID: version.ID,
+		size:      -1,
+		modTime:   version.UpdatedAt,
+	}
+	switch loc.typ {
+	case typeNpm:
+		o.remote = path.Join(dir, f.opt.Enc.ToStandardName(loc.name+"-"+loc.version+".tgz"))
+		return f.npmFiles(ctx, loc, o)
+	case typeContainer:
+		o.remote = path.Join(dir, manifestName)
+		return f.containerFiles(loc, o), nil
+	case typeMaven:
+		o.remote = dir
+		return f.mavenFiles(ctx, loc, o)
+	}
+	return nil, fs.ErrorDirNotFound
+}
+
+// NewObject finds the Object at remote.  If it can't be found
+// it returns the error fs.ErrorObjectNotFound.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	loc, err := f.locate(remote)
+	if err != nil {
+		return nil, err
+	}
+	if loc.depth != 4 || !knownType(loc.typ) {
+		return nil, fs.ErrorObjectNotFound
+	}
+	version, err := f.findVersion(ctx, loc)
+	if err == fs.ErrorDirNotFound {
+		return nil, fs.ErrorObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	objects, err := f.listVersionFiles(ctx, path.Dir(remote), loc, version)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range objects {
+		if o.remote == remote {
+			return o, nil
+		}
+	}
+	return nil, fs.ErrorObjectNotFound
+}
+
+// List the objects and directories in dir into entries.  The
+// entries can be returned in any order but should be for a
+// complete directory.
+//
+// dir should be "" to list the root, and should not have
+// trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	loc, err := f.locate(dir)
+	if err != nil {
+		return nil, err
+	}
+	if loc.depth >= 1 && !knownType(loc.typ) {
+		return nil, fs.ErrorDirNotFound
+	}
+	switch loc.depth {
+	case 0:
+		for _, typ := range packageTypes {
+			entries = append(entries, fs.NewDir(typ, time.Time{}))
+		}
+	case 1:
+		packages, err := f.listPackages(ctx, loc.typ)
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range packages {
+			remote := path.Join(dir, f.opt.Enc.ToStandardName(pkg.Name))
+			entries = append(entries, fs.NewDir(remote, pkg.UpdatedAt).SetID(strconv.FormatInt(pkg.ID, 10)))
+		}
+	case 2:
+		versions, err := f.listVersions(ctx, loc.typ, loc.name)
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range versions {
+			remote := path.Join(dir, f.opt.Enc.ToStandardName(version.Name))
+			entries = append(entries, fs.NewDir(remote, version.UpdatedAt).SetID(strconv.FormatInt(version.ID, 10)))
+		}
+	case 3:
+		version, err := f.findVersion(ctx, loc)
+		if err != nil {
+			return nil, err
+		}
+		objects, err := f.listVersionFiles(ctx, dir, loc, version)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range objects {
+			entries = append(entries, o)
+		}
+	default:
+		return nil, fs.ErrorDirNotFound
+	}
+	return entries, nil
+}
+
+// Put the object
+//
+// Packages can only be published with the package managers so this
+// always returns an error.
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	return nil, errReadOnly
+}
+
+// Mkdir creates the directory if it doesn't exist
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	return errReadOnly
+}
+
+// Rmdir deletes the directory if it is empty
+//
+// Directories are made from the packages so this can only check the
+// directory is empty.
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	entries, err := f.List(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) != 0 {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	return nil
+}
+
+// deletePackage deletes the package name of type typ with all its
+// versions
+func (f *Fs) deletePackage(ctx context.Context, typ, name string) error {
+	opts := rest.Opts{
+		Method:     "DELETE",
+		Path:       f.packagePath(typ, name),
+		NoResponse: true,
+	}
+	return f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+}
+
+// deleteVersion deletes the version with versionID of the package
+// name of type typ
+//
+// GitHub refuses to delete the last version of a package so the
+// whole package is deleted in that case.
+func (f *Fs) deleteVersion(ctx context.Context, typ, name string, versionID int64) error {
+	versions, err := f.listVersions(ctx, typ, name)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 1 && versions[0].ID == versionID {
+		return f.deletePackage(ctx, typ, name)
+	}
+	opts := rest.Opts{
+		Method:     "DELETE",
+		Path:       f.packagePath(typ, name) + "/versions/" + strconv.FormatInt(versionID, 10),
+		NoResponse: true,
+	}
+	return f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+}
+
+// Purge deletes the package or version in the directory
+//
+// This only works for package and version directories.
+func (f *Fs) Purge(ctx context.Context, dir string) error {
+	loc, err := f.locate(dir)
+	if err != nil {
+		return err
+	}
+	if loc.depth != 2 && loc.depth != 3 {
+		return fs.ErrorCantPurge
+	}
+	if !knownType(loc.typ) {
+		return fs.ErrorDirNotFound
+	}
+	if loc.depth == 2 {
+		_, err = f.getPackage(ctx, loc.typ, loc.name)
+		if err != nil {
+			return err
+		}
+		err = f.deletePackage(ctx, loc.typ, loc.name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete package %s", loc.name)
+		}
+		return nil
+	}
+	version, err := f.findVersion(ctx, loc)
+	if err != nil {
+		return err
+	}
+	err = f.deleteVersion(ctx, loc.typ, loc.name, version.ID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete package %s version %s", loc.name, loc.version)
+	}
+	return nil
+}
+
+// Precision of the ModTimes in this Fs
+func (f *Fs) Precision() time.Duration {
+	return fs.ModTimeNotSupported
+}
+
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() hash.Set {
+	return hash.Set(hash.SHA1)
+}
+
+// ------------------------------------------------------------
+
+// Fs returns the parent Fs
+func (o *Object) Fs() fs.Info {
+	return o.fs
+}
+
+// Return a string version
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// Remote returns the remote path
+func (o *Object) Remote() string {
+	return o.remote
+}
+
+// Hash returns the hash of an object returning a lowercase hex string
+//
+// Only npm packages have a SHA-1 checksum.
+func (o *Object) Hash(ctx context.Context, t hash.Type) (string, error) {
+	if t != hash.SHA1 {
+		return "", hash.ErrUnsupported
+	}
+	return o.sha1, nil
+}
+
+// Size returns the size of an object in bytes
+func (o *Object) Size() int64 {
+	return o.size
+}
+
+// ModTime returns the modification time of the object
+//
+// This is the time the package version was last updated
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	return o.modTime
+}
+
+// SetModTime sets the modification time of the object
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	return fs.ErrorCantSetModTime
+}
+
+// Storable returns a boolean showing whether this object storable
+func (o *Object) Storable() bool {
+	return true
+}
+
+// ID returns the ID of the package version of the Object
+func (o *Object) ID() string {
+	return strconv.FormatInt(o.versionID, 10)
+}
+
+// Open an object for read
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
+	fs.FixRangeOption(options, o.size)
+	opts := rest.Opts{
+		Method:  "GET",
+		RootURL: o.url,
+		Options: options,
+	}
+	if o.pkgType == typeContainer {
+		token, err := o.fs.containerToken(ctx, o.pkgName)
+		if err != nil {
+			return nil, err
+		}
+		opts.ExtraHeaders = map[string]string{
+			"Authorization": "Bearer " + token,
+			"Accept":        manifestAccept,
+		}
+	} else {
+		o.fs.registryAuth(o.pkgType, &opts)
+	}
+	var resp *http.Response
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.reg.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, err
+}
+
+// Update the object with the contents of the io.Reader, modTime and size
+//
+// Packages can only be published with the package managers so this
+// always returns an error.
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (err error) {
+	return errReadOnly
+}
+
+// Remove an object
+//
+// npm and container versions only have one file so this deletes the
+// package version.  Maven versions have several files which can only
+// be deleted together with purge.
+func (o *Object) Remove(ctx context.Context) error {
+	if o.pkgType == typeMaven {
+		return errors.New("files of maven packages can't be removed on their own - purge the version directory instead")
+	}
+	return o.fs.deleteVersion(ctx, o.pkgType, o.pkgName, o.versionID)
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs     = &Fs{}
+	_ fs.Purger = &Fs{}
+	_ fs.Object = &Object{}
+	_ fs.IDer   = &Object{}
+)
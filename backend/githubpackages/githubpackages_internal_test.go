@@ -0,0 +1,174 @@
+package githubpackages
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocate(t *testing.T) {
+	enc := encoder.Display | encoder.EncodeInvalidUtf8
+	for _, test := range []struct {
+		root    string
+		in      string
+		want    location
+		wantErr bool
+	}{
+		{"", "", location{}, false},
+		{"", "npm", location{depth: 1, typ: "npm"}, false},
+		{"npm", "pkg", location{depth: 2, typ: "npm", name: "pkg"}, false},
+		{"container/app／web", "sha256:abc", location{depth: 3, typ: "container", name: "app/web", version: "sha256:abc"}, false},
+		{"npm/pkg/1.0.0", "pkg-1.0.0.tgz", location{depth: 4, typ: "npm", name: "pkg", version: "1.0.0", file: "pkg-1.0.0.tgz"}, false},
+		{"", "npm/pkg/1.0.0/file/extra", location{}, true},
+	} {
+		f := &Fs{
+			root: test.root,
+			opt: Options{
+				Enc: enc,
+			},
+		}
+		got, err := f.locate(test.in)
+		what := test.root + "|" + test.in
+		if test.wantErr {
+			assert.Error(t, err, what)
+			continue
+		}
+		require.NoError(t, err, what)
+		assert.Equal(t, test.want, got, what)
+	}
+}
+
+// prepare starts a test server standing in for the API and the
+// registries and returns an Fs using it
+func prepare(t *testing.T) (fs.Fs, func()) {
+	var ts *httptest.Server
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET /users/me/packages":
+			assert.Equal(t, "npm", r.URL.Query().Get("package_type"))
+			// return the packages in two pages to check pagination
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/users/me/packages?package_type=npm&page=2>; rel="next"`, ts.URL))
+				_, _ = fmt.Fprint(w, `[{"id":1,"name":"pkg","updated_at":"2021-01-02T03:04:05Z"}]`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `[{"id":2,"name":"other","updated_at":"2021-01-02T03:04:05Z"}]`)
+		case "GET /users/me/packages/npm/pkg/versions":
+			_, _ = fmt.Fprint(w, `[{"id":11,"name":"1.0.0","updated_at":"2021-02-03T04:05:06Z"},{"id":12,"name":"2.0.0","updated_at":"2021-03-04T05:06:07Z"}]`)
+		case "GET /@me%2Fpkg":
+			assert.Equal(t, "Bearer TOKEN", r.Header.Get("Authorization"))
+			_, _ = fmt.Fprintf(w, `{"name":"@me/pkg","versions":{"1.0.0":{"version":"1.0.0","dist":{"tarball":"%s/tarballs/pkg-1.0.0.tgz","shasum":"aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"}}}}`, ts.URL)
+		case "GET /tarballs/pkg-1.0.0.tgz":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = fmt.Fprint(w, "hello")
+		case "GET /users/me/packages/container/app/versions":
+			_, _ = fmt.Fprint(w, `[{"id":21,"name":"sha256:abc","updated_at":"2021-02-03T04:05:06Z","metadata":{"package_type":"container","container":{"tags":["latest"]}}}]`)
+		case "GET /users/me/packages/maven/org.example.app/versions":
+			_, _ = fmt.Fprint(w, `[{"id":31,"name":"1.0","updated_at":"2021-02-03T04:05:06Z"}]`)
+		case "GET /users/me/packages/maven/org.example.app":
+			_, _ = fmt.Fprint(w, `{"id":3,"name":"org.example.app","repository":{"name":"repo","full_name":"me/repo"}}`)
+		case "HEAD /me/repo/org/example/app/1.0/app-1.0.pom":
+			user, pass, _ := r.BasicAuth()
+			assert.Equal(t, "me", user)
+			assert.Equal(t, "TOKEN", pass)
+			w.Header().Set("Content-Length", "10")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"message":"Not Found"}`)
+		}
+	})
+	ts = httptest.NewServer(handler)
+	oldAPIURL, oldNpmURL, oldMavenURL, oldContainerURL := apiURL, npmURL, mavenURL, containerURL
+	apiURL, npmURL, mavenURL, containerURL = ts.URL, ts.URL, ts.URL, ts.URL
+	f, err := NewFs(context.Background(), "TestGithubPackages", "", configmap.Simple{
+		"token": "TOKEN",
+		"owner": "me",
+	})
+	require.NoError(t, err)
+	return f, func() {
+		apiURL, npmURL, mavenURL, containerURL = oldAPIURL, oldNpmURL, oldMavenURL, oldContainerURL
+		ts.Close()
+	}
+}
+
+// listNames lists dir returning the sorted remotes
+func listNames(t *testing.T, f fs.Fs, dir string) []string {
+	entries, err := f.List(context.Background(), dir)
+	require.NoError(t, err, dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Remote())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestList(t *testing.T) {
+	f, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	assert.Equal(t, []string{"container", "maven", "npm"}, listNames(t, f, ""))
+	assert.Equal(t, []string{"npm/other", "npm/pkg"}, listNames(t, f, "npm"))
+	assert.Equal(t, []string{"npm/pkg/1.0.0", "npm/pkg/2.0.0"}, listNames(t, f, "npm/pkg"))
+	assert.Equal(t, []string{"npm/pkg/1.0.0/pkg-1.0.0.tgz"}, listNames(t, f, "npm/pkg/1.0.0"))
+	assert.Equal(t, []string{"container/app/sha256:abc/manifest.json"}, listNames(t, f, "container/app/sha256:abc"))
+	assert.Equal(t, []string{"maven/org.example.app/1.0/app-1.0.pom"}, listNames(t, f, "maven/org.example.app/1.0"))
+
+	// the version directories carry the version time and ID
+	entries, err := f.List(ctx, "npm/pkg")
+	require.NoError(t, err)
+	sort.Sort(entries)
+	assert.Equal(t, time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC), entries[0].ModTime(ctx))
+	assert.Equal(t, "11", entries[0].(fs.IDer).ID())
+
+	_, err = f.List(ctx, "potato")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+	_, err = f.List(ctx, "npm/potato")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+	_, err = f.List(ctx, "npm/pkg/3.0.0")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+}
+
+func TestObject(t *testing.T) {
+	f, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	o, err := f.NewObject(ctx, "npm/pkg/1.0.0/pkg-1.0.0.tgz")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC), o.ModTime(ctx))
+	assert.Equal(t, "11", o.(fs.IDer).ID())
+	sha1, err := o.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", sha1)
+
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "hello", string(data))
+
+	o, err = f.NewObject(ctx, "maven/org.example.app/1.0/app-1.0.pom")
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), o.Size())
+
+	_, err = f.NewObject(ctx, "maven/org.example.app/1.0/app-1.0.jar")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+	_, err = f.NewObject(ctx, "npm/pkg/2.0.0/pkg-2.0.0.tgz")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+}
@@ -0,0 +1,66 @@
+// Package api has type definitions for the GitLab package registry
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Error is returned by the API when something goes wrong
+//
+// The message is either a string or an object describing validation
+// errors so it is kept raw.
+type Error struct {
+	StatusCode int             `json:"-"`
+	Message    json.RawMessage `json:"message"`
+	ErrorText  string          `json:"error"`
+}
+
+// Error returns a string for the error and satisfies the error interface
+func (e *Error) Error() string {
+	out := fmt.Sprintf("gitlab error %d", e.StatusCode)
+	if len(e.Message) > 0 {
+		var message string
+		if json.Unmarshal(e.Message, &message) == nil {
+			out += ": " + message
+		} else {
+			out += ": " + string(e.Message)
+		}
+	}
+	if e.ErrorText != "" {
+		out += ": " + e.ErrorText
+	}
+	return out
+}
+
+// Group describes a GitLab group
+type Group struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	FullPath string `json:"full_path"`
+}
+
+// Package describes a package in the package registry
+type Package struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	PackageType string    `json:"package_type"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	ProjectID   int64     `json:"project_id"`
+	ProjectPath string    `json:"project_path"`
+}
+
+// PackageFile describes a file in a package
+type PackageFile struct {
+	ID         int64     `json:"id"`
+	PackageID  int64     `json:"package_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	FileName   string    `json:"file_name"`
+	Size       int64     `json:"size"`
+	FileMD5    string    `json:"file_md5"`
+	FileSHA1   string    `json:"file_sha1"`
+	FileSHA256 string    `json:"file_sha256"`
+}
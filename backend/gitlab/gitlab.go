@@ -0,0 +1,881 @@
+// Package gitlab provides an interface to the generic packages in the
+// GitLab package registry.
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/gitlab/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/artifact"
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+const (
+	minSleep      = 10 * time.Millisecond
+	maxSleep      = 2 * time.Second
+	decayConstant = 2   // bigger for slower decay, exponential
+	perPage       = 100 // maximum page size the API allows
+	packageType   = "generic"
+)
+
+// errReadOnly is returned when trying to modify packages in group mode
+var errReadOnly = errors.New("packages can't be modified when listing a group - configure a project instead")
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "gitlab",
+		Description: "GitLab Package Registry",
+		NewFs:       NewFs,
+		Options: []fs.Option{{
+			Name:    "url",
+			Help:    "URL of the GitLab instance.",
+			Default: "https://gitlab.com",
+		}, {
+			Name: "project",
+			Help: `ID or path of the project, e.g. "1234" or "group/project".
+
+Either this or group must be set.`,
+		}, {
+			Name: "group",
+			Help: `ID or path of the group, e.g. "1234" or "group/subgroup".
+
+The packages of all the projects in the group are shown read only,
+with the project path as the top level directory.`,
+		}, {
+			Name: "token",
+			Help: "Access token.",
+		}, {
+			Name:    "token_type",
+			Help:    "Type of the access token.",
+			Default: "private",
+			Examples: []fs.OptionExample{{
+				Value: "private",
+				Help:  "Personal, project or group access token",
+			}, {
+				Value: "job",
+				Help:  "CI job token ($CI_JOB_TOKEN)",
+			}, {
+				Value: "deploy",
+				Help:  "Deploy token",
+			}},
+			Advanced: true,
+		}, {
+			Name:     config.ConfigEncoding,
+			Help:     config.ConfigEncodingHelp,
+			Advanced: true,
+			Default: (encoder.Display |
+				encoder.EncodeInvalidUtf8),
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	URL       string               `config:"url"`
+	Project   string               `config:"project"`
+	Group     string               `config:"group"`
+	Token     string               `config:"token"`
+	TokenType string               `config:"token_type"`
+	Enc       encoder.MultiEncoder `config:"encoding"`
+}
+
+// Fs represents a GitLab package registry
+type Fs struct {
+	name      string       // name of this remote
+	root      string       // the path we are working on if any
+	opt       Options      // parsed config options
+	features  *fs.Features // optional features
+	srv       *rest.Client // the connection to the server
+	pacer     *fs.Pacer    // pacer for API calls
+	groupPath string       // full path of the group if in group mode
+}
+
+// Object describes a file in a generic package
+type Object struct {
+	fs        *Fs       // what this object is part of
+	remote    string    // The remote path
+	project   string    // project ID or full path
+	pkgName   string    // name of the package
+	version   string    // version of the package
+	fileName  string    // name of the file in the package
+	packageID int64     // ID of the package
+	fileID    int64     // ID of the package file
+	size      int64     // size of the object
+	modTime   time.Time // creation time of the package file
+}
+
+// location is a path in the registry split into its parts
+//
+// Which of name, version and file are set is given by depth.
+type location struct {
+	project string // project ID or full path, "" for the root of a group
+	depth   int    // number of parts set below
+	name    string // package name
+	version string // package version
+	file    string // file name
+}
+
+// ------------------------------------------------------------
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	if f.opt.Group != "" {
+		return fmt.Sprintf("GitLab group %s path %q", f.opt.Group, f.root)
+	}
+	return fmt.Sprintf("GitLab project %s path %q", f.opt.Project, f.root)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// readOnly returns true if the packages can't be modified
+func (f *Fs) readOnly() bool {
+	return f.groupPath != ""
+}
+
+// locate splits rootRelativePath into its parts
+func (f *Fs) locate(rootRelativePath string) (loc location, err error) {
+	absPath := path.Join(f.root, rootRelativePath)
+	var parts []string
+	if absPath != "" {
+		parts = strings.Split(absPath, "/")
+	}
+	for i := range parts {
+		parts[i] = f.opt.Enc.FromStandardName(parts[i])
+	}
+	if f.groupPath != "" {
+		if len(parts) == 0 {
+			return loc, nil
+		}
+		loc.project = f.groupPath + "/" + parts[0]
+		parts = parts[1:]
+	} else {
+		loc.project = f.opt.Project
+	}
+	if len(parts) > 3 {
+		return loc, errors.Errorf("path %q is too deep - paths are package/version/file", absPath)
+	}
+	loc.depth = len(parts)
+	switch loc.depth {
+	case 3:
+		loc.file = parts[2]
+		fallthrough
+	case 2:
+		loc.version = parts[1]
+		fallthrough
+	case 1:
+		loc.name = parts[0]
+	}
+	return loc, nil
+}
+
+// projectPath returns the API path for the project
+func projectPath(project string) string {
+	return "/projects/" + url.PathEscape(project)
+}
+
+// retryErrorCodes is a slice of error codes that we will retry
+var retryErrorCodes = []int{
+	429, // Too Many Requests.
+	500, // Internal Server Error
+	502, // Bad Gateway
+	503, // Service Unavailable
+	504, // Gateway Timeout
+	509, // Bandwidth Limit Exceeded
+}
+
+// shouldRetry returns a boolean as to whether this resp and err
+// deserve to be retried.  It returns the err as a convenience
+func shouldRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if fserrors.ContextError(ctx, &err) {
+		return false, err
+	}
+	return fserrors.ShouldRetry(err) || fserrors.ShouldRetryHTTP(resp, retryErrorCodes), err
+}
+
+// errorHandler parses a non 2xx error response into an error
+func errorHandler(resp *http.Response) error {
+	errResponse := new(api.Error)
+	err := rest.DecodeJSON(resp, &errResponse)
+	if err != nil {
+		fs.Debugf(nil, "Couldn't decode error response: %v", err)
+	}
+	errResponse.StatusCode = resp.StatusCode
+	if len(errResponse.Message) == 0 && errResponse.ErrorText == "" {
+		errResponse.ErrorText = resp.Status
+	}
+	return errResponse
+}
+
+// isNotFound returns true if resp indicates the item wasn't found
+func isNotFound(resp *http.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusNotFound
+}
+
+// NewFs constructs an Fs from the path
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	// Parse config into Options struct
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	if (opt.Project == "") == (opt.Group == "") {
+		return nil, errors.New("exactly one of project or group must be set")
+	}
+	var tokenHeader string
+	switch opt.TokenType {
+	case "private":
+		tokenHeader = "PRIVATE-TOKEN"
+	case "job":
+		tokenHeader = "JOB-TOKEN"
+	case "deploy":
+		tokenHeader = "Deploy-Token"
+	default:
+		return nil, errors.Errorf("unknown token_type %q", opt.TokenType)
+	}
+
+	f := &Fs{
+		name:  name,
+		root:  strings.Trim(root, "/"),
+		opt:   *opt,
+		srv:   rest.NewClient(fshttp.NewClient(ctx)).SetRoot(strings.TrimRight(opt.URL, "/") + "/api/v4"),
+		pacer: fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
+	}
+	f.features = (&fs.Features{
+		DuplicateFiles: true,
+	}).Fill(ctx, f)
+	f.srv.SetErrorHandler(errorHandler)
+	if opt.Token != "" {
+		f.srv.SetHeader(tokenHeader, opt.Token)
+	}
+
+	if opt.Group != "" {
+		opts := rest.Opts{
+			Method: "GET",
+			Path:   "/groups/" + url.PathEscape(opt.Group),
+		}
+		var group api.Group
+		err = f.pacer.Call(func() (bool, error) {
+			resp, err := f.srv.CallJSON(ctx, &opts, nil, &group)
+			return shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read group %q", opt.Group)
+		}
+		f.groupPath = group.FullPath
+	}
+
+	// Check to see if the root is actually an existing file
+	loc, err := f.locate("")
+	if err != nil {
+		return nil, err
+	}
+	if loc.depth == 3 {
+		oldRoot := f.root
+		newRoot, leaf := path.Split(oldRoot)
+		f.root = strings.Trim(newRoot, "/")
+		_, err := f.NewObject(ctx, leaf)
+		if err != nil {
+			// File doesn't exist so return old f
+			f.root = oldRoot
+			return f, nil
+		}
+		// return an error with an fs which points to the parent
+		return f, fs.ErrorIsFile
+	}
+	return f, nil
+}
+
+// paginate calls the API with opts for every page of results, calling
+// fn to decode each page
+func (f *Fs) paginate(ctx context.Context, opts *rest.Opts, fn func(resp *http.Response) error) (resp *http.Response, err error) {
+	if opts.Parameters == nil {
+		opts.Parameters = url.Values{}
+	}
+	opts.Parameters.Set("per_page", strconv.Itoa(perPage))
+	err = artifact.Paginate(func(page string) (string, error) {
+		if page == "" {
+			page = "1"
+		}
+		opts.Parameters.Set("page", page)
+		err = f.pacer.Call(func() (bool, error) {
+			resp, err = f.srv.Call(ctx, opts)
+			return shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return "", err
+		}
+		return resp.Header.Get("X-Next-Page"), fn(resp)
+	})
+	return resp, err
+}
+
+// listPackages lists the generic packages of the project in loc
+// matching the name and version in loc if set
+//
+// If loc.project is empty it lists the packages of the group.
+func (f *Fs) listPackages(ctx context.Context, loc location) (packages []api.Package, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   projectPath(loc.project) + "/packages",
+		Parameters: url.Values{
+			"package_type": {packageType},
+		},
+	}
+	if loc.project == "" {
+		opts.Path = "/groups/" + url.PathEscape(f.opt.Group) + "/packages"
+	}
+	if loc.depth >= 1 {
+		// this is a fuzzy match so the names are checked below
+		opts.Parameters.Set("package_name", loc.name)
+	}
+	resp, err := f.paginate(ctx, &opts, func(resp *http.Response) error {
+		var result []api.Package
+		err := rest.DecodeJSON(resp, &result)
+		if err != nil {
+			return err
+		}
+		for _, pkg := range result {
+			if pkg.Status != "" && pkg.Status != "default" {
+				continue
+			}
+			if loc.depth >= 1 && pkg.Name != loc.name {
+				continue
+			}
+			if loc.depth >= 2 && pkg.Version != loc.version {
+				continue
+			}
+			packages = append(packages, pkg)
+		}
+		return nil
+	})
+	if err != nil {
+		if isNotFound(resp) {
+			return nil, fs.ErrorDirNotFound
+		}
+		return nil, errors.Wrap(err, "failed to list packages")
+	}
+	return packages, nil
+}
+
+// listPackageFiles lists the files in the package with packageID
+func (f *Fs) listPackageFiles(ctx context.Context, project string, packageID int64) (files []api.PackageFile, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   projectPath(project) + "/packages/" + strconv.FormatInt(packageID, 10) + "/package_files",
+	}
+	_, err = f.paginate(ctx, &opts, func(resp *http.Response) error {
+		var result []api.PackageFile
+		err := rest.DecodeJSON(resp, &result)
+		if err != nil {
+			return err
+		}
+		files = append(files, result...)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list package files")
+	}
+	return files, nil
+}
+
+// listFiles calls fn for every file of the packages at loc
+func (f *Fs) listFiles(ctx context.Context, loc location, fn func(pkg *api.Package, file *api.PackageFile) error) (found bool, err error) {
+	packages, err := f.listPackages(ctx, loc)
+	if err != nil {
+		return false, err
+	}
+	for i := range packages {
+		pkg := &packages[i]
+		files, err := f.listPackageFiles(ctx, loc.project, pkg.ID)
+		if err != nil {
+			return false, err
+		}
+		for j := range files {
+			err = fn(pkg, &files[j])
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	return len(packages) > 0, nil
+}
+
+// newObject makes an Object for file in pkg
+func (f *Fs) newObject(remote string, loc location, pkg *api.Package, file *api.PackageFile) *Object {
+	return &Object{
+		fs:        f,
+		remote:    remote,
+		project:   loc.project,
+		pkgName:   pkg.Name,
+		version:   pkg.Version,
+		fileName:  file.FileName,
+		packageID: pkg.ID,
+		fileID:    file.ID,
+		size:      file.Size,
+		modTime:   file.CreatedAt,
+	}
+}
+
+// NewObject finds the Object at remote.  If it can't be found
+// it returns the error fs.ErrorObjectNotFound.
+//
+// If there are several files with the same name the newest is
+// returned.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	loc, err := f.locate(remote)
+	if err != nil {
+		return nil, err
+	}
+	if loc.project == "" || loc.depth != 3 {
+		return nil, fs.ErrorObjectNotFound
+	}
+	var o *Object
+	_, err = f.listFiles(ctx, loc, func(pkg *api.Package, file *api.PackageFile) error {
+		if file.FileName == loc.file && (o == nil || file.ID > o.fileID) {
+			o = f.newObject(remote, loc, pkg, file)
+		}
+		return nil
+	})
+	if err == fs.ErrorDirNotFound {
+		return nil, fs.ErrorObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		return nil, fs.ErrorObjectNotFound
+	}
+	return o, nil
+}
+
+// dirEntries makes directories in dir from the leaf names and
+// modification times in dirs
+func (f *Fs) dirEntries(dir string, dirs map[string]time.Time) (entries fs.DirEntries) {
+	for leaf, modTime := range dirs {
+		entries = append(entries, fs.NewDir(path.Join(dir, f.opt.Enc.ToStandardName(leaf)), modTime))
+	}
+	return entries
+}
+
+// addDir records a directory called leaf with modTime in dirs,
+// keeping the newest modification time
+func addDir(dirs map[string]time.Time, leaf string, modTime time.Time) {
+	if t, ok := dirs[leaf]; !ok || modTime.After(t) {
+		dirs[leaf] = modTime
+	}
+}
+
+// List the objects and directories in dir into entries.  The
+// entries can be returned in any order but should be for a
+// complete directory.
+//
+// dir should be "" to list the root, and should not have
+// trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	loc, err := f.locate(dir)
+	if err != nil {
+		return nil, err
+	}
+	dirs := map[string]time.Time{}
+	switch {
+	case loc.project == "":
+		packages, err := f.listPackages(ctx, loc)
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range packages {
+			addDir(dirs, strings.TrimPrefix(pkg.ProjectPath, f.groupPath+"/"), pkg.CreatedAt)
+		}
+		entries = f.dirEntries(dir, dirs)
+	case loc.depth == 0 || loc.depth == 1:
+		packages, err := f.listPackages(ctx, loc)
+		if err != nil {
+			return nil, err
+		}
+		if loc.depth == 1 && len(packages) == 0 {
+			return nil, fs.ErrorDirNotFound
+		}
+		for _, pkg := range packages {
+			leaf := pkg.Name
+			if loc.depth == 1 {
+				leaf = pkg.Version
+			}
+			addDir(dirs, leaf, pkg.CreatedAt)
+		}
+		entries = f.dirEntries(dir, dirs)
+	case loc.depth == 2:
+		found, err := f.listFiles(ctx, loc, func(pkg *api.Package, file *api.PackageFile) error {
+			remote := path.Join(dir, f.opt.Enc.ToStandardName(file.FileName))
+			entries = append(entries, f.newObject(remote, loc, pkg, file))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fs.ErrorDirNotFound
+		}
+	default:
+		return nil, fs.ErrorDirNotFound
+	}
+	return entries, nil
+}
+
+// Put the object into the package registry
+//
+// Copy the reader in to the new object which is returned
+//
+// The new object may have been created if an error is returned
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	existingObj, err := f.NewObject(ctx, src.Remote())
+	switch err {
+	case nil:
+		return existingObj, existingObj.Update(ctx, in, src, options...)
+	case fs.ErrorObjectNotFound:
+		// Not found so create it
+		return f.PutUnchecked(ctx, in, src, options...)
+	default:
+		return nil, err
+	}
+}
+
+// PutUnchecked uploads the object
+//
+// This will create a duplicate if we upload a new file without
+// checking to see if there is one already - use Put() for that.
+func (f *Fs) PutUnchecked(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	o := &Object{
+		fs:     f,
+		remote: src.Remote(),
+	}
+	return o, o.upload(ctx, in, src, options...)
+}
+
+// Mkdir creates the directory if it doesn't exist
+//
+// Directories are made from the package names and versions so there
+// is nothing to do here.
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	_, err := f.locate(dir)
+	return err
+}
+
+// deletePackage deletes the package with packageID and all its files
+func (f *Fs) deletePackage(ctx context.Context, project string, packageID int64) error {
+	opts := rest.Opts{
+		Method:     "DELETE",
+		Path:       projectPath(project) + "/packages/" + strconv.FormatInt(packageID, 10),
+		NoResponse: true,
+	}
+	return f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+}
+
+// Rmdir deletes the directory if it is empty
+//
+// Returns an error if it isn't empty
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	entries, err := f.List(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) != 0 {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	loc, err := f.locate(dir)
+	if err != nil {
+		return err
+	}
+	if loc.depth != 2 || f.readOnly() {
+		return nil
+	}
+	// Remove any packages left without files
+	packages, err := f.listPackages(ctx, loc)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range packages {
+		err = f.deletePackage(ctx, loc.project, pkg.ID)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete empty package")
+		}
+	}
+	return nil
+}
+
+// Purge deletes all the packages in the directory
+//
+// This only works for package name and version directories.
+func (f *Fs) Purge(ctx context.Context, dir string) error {
+	if f.readOnly() {
+		return errReadOnly
+	}
+	loc, err := f.locate(dir)
+	if err != nil {
+		return err
+	}
+	if loc.depth != 1 && loc.depth != 2 {
+		return fs.ErrorCantPurge
+	}
+	packages, err := f.listPackages(ctx, loc)
+	if err != nil {
+		return err
+	}
+	if len(packages) == 0 {
+		return fs.ErrorDirNotFound
+	}
+	for _, pkg := range packages {
+		err = f.deletePackage(ctx, loc.project, pkg.ID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete package %s %s", pkg.Name, pkg.Version)
+		}
+	}
+	return nil
+}
+
+// Precision of the ModTimes in this Fs
+func (f *Fs) Precision() time.Duration {
+	return fs.ModTimeNotSupported
+}
+
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() hash.Set {
+	return hash.Set(hash.None)
+}
+
+// ------------------------------------------------------------
+
+// Fs returns the parent Fs
+func (o *Object) Fs() fs.Info {
+	return o.fs
+}
+
+// Return a string version
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// Remote returns the remote path
+func (o *Object) Remote() string {
+	return o.remote
+}
+
+// Hash returns the hash of an object returning a lowercase hex string
+func (o *Object) Hash(ctx context.Context, t hash.Type) (string, error) {
+	return "", hash.ErrUnsupported
+}
+
+// Size returns the size of an object in bytes
+func (o *Object) Size() int64 {
+	return o.size
+}
+
+// ModTime returns the modification time of the object
+//
+// This is the time the file was added to the package
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	return o.modTime
+}
+
+// SetModTime sets the modification time of the object
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	return fs.ErrorCantSetModTime
+}
+
+// Storable returns a boolean showing whether this object storable
+func (o *Object) Storable() bool {
+	return true
+}
+
+// ID returns the ID of the Object if known, or "" if not
+func (o *Object) ID() string {
+	return strconv.FormatInt(o.fileID, 10)
+}
+
+// genericPath returns the API path of the file in the generic
+// packages API
+func (o *Object) genericPath() string {
+	return projectPath(o.project) + "/packages/" + packageType + "/" +
+		url.PathEscape(o.pkgName) + "/" +
+		url.PathEscape(o.version) + "/" +
+		url.PathEscape(o.fileName)
+}
+
+// Open an object for read
+//
+// The generic packages API always returns the newest file with the
+// name in the package.
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
+	fs.FixRangeOption(options, o.size)
+	opts := rest.Opts{
+		Method:  "GET",
+		Path:    o.genericPath(),
+		Options: options,
+	}
+	var resp *http.Response
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, err
+}
+
+// upload the contents of in to the object, setting o from the
+// uploaded file
+func (o *Object) upload(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (err error) {
+	if o.fs.readOnly() {
+		return errReadOnly
+	}
+	loc, err := o.fs.locate(o.remote)
+	if err != nil {
+		return err
+	}
+	if loc.depth != 3 {
+		return errors.Errorf("can't upload %q - files must be in package/version directories", o.remote)
+	}
+	o.project, o.pkgName, o.version, o.fileName = loc.project, loc.name, loc.version, loc.file
+	size := src.Size()
+	opts := rest.Opts{
+		Method:  "PUT",
+		Path:    o.genericPath(),
+		Body:    in,
+		Options: options,
+		Parameters: url.Values{
+			"select": {"package_file"},
+		},
+	}
+	if size >= 0 {
+		opts.ContentLength = &size
+	}
+	var file api.PackageFile
+	var resp *http.Response
+	err = o.fs.pacer.CallNoRetry(func() (bool, error) {
+		resp, err = o.fs.srv.CallJSON(ctx, &opts, nil, &file)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "upload failed")
+	}
+	if file.ID != 0 {
+		o.packageID = file.PackageID
+		o.fileID = file.ID
+		o.size = file.Size
+		o.modTime = file.CreatedAt
+		return nil
+	}
+	// Older GitLab versions don't return the file so look it up
+	newObj, err := o.fs.NewObject(ctx, o.remote)
+	if err != nil {
+		return errors.Wrap(err, "failed to read uploaded file")
+	}
+	*o = *newObj.(*Object)
+	return nil
+}
+
+// Update the object with the contents of the io.Reader, modTime and size
+//
+// Uploading adds a new file to the package so the old one is removed
+// afterwards.
+//
+// The new object may have been created if an error is returned
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (err error) {
+	old := *o
+	err = o.upload(ctx, in, src, options...)
+	if err != nil {
+		return err
+	}
+	if old.fileID != 0 && old.fileID != o.fileID {
+		err = old.Remove(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to remove old version")
+		}
+	}
+	return nil
+}
+
+// Remove an object
+//
+// If this leaves the package empty the package is removed too.
+func (o *Object) Remove(ctx context.Context) error {
+	if o.fs.readOnly() {
+		return errReadOnly
+	}
+	opts := rest.Opts{
+		Method:     "DELETE",
+		Path:       projectPath(o.project) + "/packages/" + strconv.FormatInt(o.packageID, 10) + "/package_files/" + strconv.FormatInt(o.fileID, 10),
+		NoResponse: true,
+	}
+	var resp *http.Response
+	var err error
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if isNotFound(resp) {
+			return fs.ErrorObjectNotFound
+		}
+		return err
+	}
+	files, err := o.fs.listPackageFiles(ctx, o.project, o.packageID)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return o.fs.deletePackage(ctx, o.project, o.packageID)
+	}
+	return nil
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs             = &Fs{}
+	_ fs.PutUncheckeder = &Fs{}
+	_ fs.Purger         = &Fs{}
+	_ fs.Object         = &Object{}
+	_ fs.IDer           = &Object{}
+)
@@ -0,0 +1,179 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocate(t *testing.T) {
+	enc := encoder.Display | encoder.EncodeInvalidUtf8
+	for _, test := range []struct {
+		groupPath string
+		root      string
+		in        string
+		want      location
+		wantErr   bool
+	}{
+		{"", "", "", location{project: "proj"}, false},
+		{"", "", "pkg", location{project: "proj", depth: 1, name: "pkg"}, false},
+		{"", "pkg", "1.0", location{project: "proj", depth: 2, name: "pkg", version: "1.0"}, false},
+		{"", "pkg/1.0", "file.tar.gz", location{project: "proj", depth: 3, name: "pkg", version: "1.0", file: "file.tar.gz"}, false},
+		{"", "", "pkg/1.0/file/extra", location{}, true},
+		{"group", "", "", location{}, false},
+		{"group", "", "sub／proj", location{project: "group/sub/proj"}, false},
+		{"group", "sub／proj", "pkg/1.0/file", location{project: "group/sub/proj", depth: 3, name: "pkg", version: "1.0", file: "file"}, false},
+	} {
+		f := &Fs{
+			root:      test.root,
+			groupPath: test.groupPath,
+			opt: Options{
+				Project: "proj",
+				Enc:     enc,
+			},
+		}
+		got, err := f.locate(test.in)
+		what := test.root + "|" + test.in
+		if test.wantErr {
+			assert.Error(t, err, what)
+			continue
+		}
+		require.NoError(t, err, what)
+		assert.Equal(t, test.want, got, what)
+	}
+}
+
+// testPackages are the generic packages of the grp/proj project
+const testPackages = `[
+{"id":1,"name":"pkg","version":"1.0","package_type":"generic","status":"default","created_at":"2021-01-01T00:00:00Z","project_path":"grp/proj"},
+{"id":2,"name":"pkg","version":"2.0","package_type":"generic","status":"default","created_at":"2021-02-01T00:00:00Z","project_path":"grp/proj"},
+{"id":3,"name":"pkg-extra","version":"1.0","package_type":"generic","status":"default","created_at":"2021-03-01T00:00:00Z","project_path":"grp/proj"},
+{"id":4,"name":"pkg","version":"3.0","package_type":"generic","status":"pending_destruction","created_at":"2021-04-01T00:00:00Z","project_path":"grp/proj"}
+]`
+
+// prepare starts a test server standing in for the GitLab API and
+// returns an Fs using it configured with m
+func prepare(t *testing.T, m configmap.Simple) (fs.Fs, func()) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "TOKEN", r.Header.Get("PRIVATE-TOKEN"))
+		w.Header().Set("Content-Type", "application/json")
+		query := r.URL.Query()
+		switch r.URL.EscapedPath() {
+		case "/api/v4/groups/grp":
+			_, _ = fmt.Fprint(w, `{"id":1,"name":"grp","full_path":"grp"}`)
+		case "/api/v4/groups/grp/packages":
+			assert.Equal(t, "generic", query.Get("package_type"))
+			_, _ = fmt.Fprint(w, `[
+{"id":1,"name":"pkg","version":"1.0","status":"default","created_at":"2021-01-01T00:00:00Z","project_path":"grp/proj"},
+{"id":5,"name":"tool","version":"0.1","status":"default","created_at":"2021-01-01T00:00:00Z","project_path":"grp/sub/other"}
+]`)
+		case "/api/v4/projects/grp%2Fproj/packages":
+			assert.Equal(t, "generic", query.Get("package_type"))
+			// return the packages over two pages to check pagination
+			if query.Get("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				_, _ = fmt.Fprint(w, `[]`)
+				return
+			}
+			_, _ = fmt.Fprint(w, testPackages)
+		case "/api/v4/projects/grp%2Fsub%2Fother/packages":
+			_, _ = fmt.Fprint(w, `[{"id":5,"name":"tool","version":"0.1","status":"default","created_at":"2021-01-01T00:00:00Z","project_path":"grp/sub/other"}]`)
+		case "/api/v4/projects/grp%2Fproj/packages/1/package_files":
+			_, _ = fmt.Fprint(w, `[
+{"id":10,"package_id":1,"created_at":"2021-01-01T00:00:00Z","file_name":"file.txt","size":3},
+{"id":11,"package_id":1,"created_at":"2021-01-02T00:00:00Z","file_name":"file.txt","size":5},
+{"id":12,"package_id":1,"created_at":"2021-01-01T00:00:00Z","file_name":"other.txt","size":1}
+]`)
+		case "/api/v4/projects/grp%2Fproj/packages/generic/pkg/1.0/file.txt":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = fmt.Fprint(w, "hello")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"message":"404 Not Found"}`)
+		}
+	})
+	ts := httptest.NewServer(handler)
+	m["url"] = ts.URL
+	m["token"] = "TOKEN"
+	f, err := NewFs(context.Background(), "TestGitlab", "", m)
+	require.NoError(t, err)
+	return f, ts.Close
+}
+
+// listNames lists dir returning the sorted remotes
+func listNames(t *testing.T, f fs.Fs, dir string) []string {
+	entries, err := f.List(context.Background(), dir)
+	require.NoError(t, err, dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Remote())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestListProject(t *testing.T) {
+	f, tidy := prepare(t, configmap.Simple{"project": "grp/proj"})
+	defer tidy()
+	ctx := context.Background()
+
+	assert.Equal(t, []string{"pkg", "pkg-extra"}, listNames(t, f, ""))
+	// the fuzzy matched pkg-extra and pending packages aren't shown
+	assert.Equal(t, []string{"pkg/1.0", "pkg/2.0"}, listNames(t, f, "pkg"))
+	// duplicate files are all shown
+	assert.Equal(t, []string{"pkg/1.0/file.txt", "pkg/1.0/file.txt", "pkg/1.0/other.txt"}, listNames(t, f, "pkg/1.0"))
+
+	_, err := f.List(ctx, "potato")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+	_, err = f.List(ctx, "pkg/3.0")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+	_, err = f.List(ctx, "pkg/1.0/file.txt/potato")
+	assert.Error(t, err)
+}
+
+func TestListGroup(t *testing.T) {
+	f, tidy := prepare(t, configmap.Simple{"group": "grp"})
+	defer tidy()
+
+	// the project paths are relative to the group with / encoded
+	assert.Equal(t, []string{"proj", "sub／other"}, listNames(t, f, ""))
+	assert.Equal(t, []string{"sub／other/tool"}, listNames(t, f, "sub／other"))
+	assert.Equal(t, []string{"proj/pkg/1.0", "proj/pkg/2.0"}, listNames(t, f, "proj/pkg"))
+}
+
+func TestObject(t *testing.T) {
+	f, tidy := prepare(t, configmap.Simple{"project": "grp/proj"})
+	defer tidy()
+	ctx := context.Background()
+
+	// the newest of the duplicates is found
+	o, err := f.NewObject(ctx, "pkg/1.0/file.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), o.Size())
+	assert.Equal(t, "11", o.(fs.IDer).ID())
+	_, err = o.Hash(ctx, hash.MD5)
+	assert.Equal(t, hash.ErrUnsupported, err)
+
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "hello", string(data))
+
+	_, err = f.NewObject(ctx, "pkg/1.0/potato.txt")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+	_, err = f.NewObject(ctx, "pkg/3.0/file.txt")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+}
@@ -0,0 +1,41 @@
+// Package api has type definitions for maven repositories
+package api
+
+import "encoding/xml"
+
+// Metadata is the content of a maven-metadata.xml file
+//
+// Depending on the directory it is in it describes the versions of an
+// artifact, the files of a snapshot version or the plugins of a group.
+type Metadata struct {
+	XMLName    xml.Name   `xml:"metadata"`
+	GroupID    string     `xml:"groupId"`
+	ArtifactID string     `xml:"artifactId"`
+	Version    string     `xml:"version"`
+	Versioning Versioning `xml:"versioning"`
+	Plugins    []Plugin   `xml:"plugins>plugin"`
+}
+
+// Versioning is the versioning section of the metadata
+type Versioning struct {
+	Latest           string            `xml:"latest"`
+	Release          string            `xml:"release"`
+	Versions         []string          `xml:"versions>version"`
+	LastUpdated      string            `xml:"lastUpdated"`
+	SnapshotVersions []SnapshotVersion `xml:"snapshotVersions>snapshotVersion"`
+}
+
+// SnapshotVersion is a file of a snapshot version
+type SnapshotVersion struct {
+	Classifier string `xml:"classifier"`
+	Extension  string `xml:"extension"`
+	Value      string `xml:"value"`
+	Updated    string `xml:"updated"`
+}
+
+// Plugin is a maven plugin in the metadata of a group
+type Plugin struct {
+	Name       string `xml:"name"`
+	Prefix     string `xml:"prefix"`
+	ArtifactID string `xml:"artifactId"`
+}
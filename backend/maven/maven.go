@@ -0,0 +1,667 @@
+// Package maven provides a read only interface to maven2 repositories
+// served over HTTP.
+//
+// Directories are listed from the HTML indexes served by most
+// repositories, falling back to the maven-metadata.xml files when
+// there isn't an index.
+package maven
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/maven/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/artifact"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+	"golang.org/x/net/html"
+)
+
+const (
+	minSleep      = 10 * time.Millisecond
+	maxSleep      = 2 * time.Second
+	decayConstant = 2 // bigger for slower decay, exponential
+	metadataName  = "maven-metadata.xml"
+)
+
+var (
+	errorReadOnly = errors.New("maven remotes are read only")
+	errNoIndex    = errors.New("no directory index")
+	timeUnset     = time.Unix(0, 0)
+)
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "maven",
+		Description: "Maven repository over HTTP",
+		NewFs:       NewFs,
+		Options: []fs.Option{{
+			Name:     "url",
+			Help:     "URL of the root of the maven2 repository.",
+			Required: true,
+			Examples: []fs.OptionExample{{
+				Value: "https://repo.maven.apache.org/maven2",
+				Help:  "Maven Central",
+			}, {
+				Value: "https://repo.example.com/releases",
+				Help:  "Any other maven2 repository",
+			}},
+		}, {
+			Name: "user",
+			Help: "User name.\n\nLeave blank for anonymous access.",
+		}, {
+			Name:       "pass",
+			Help:       "Password.",
+			IsPassword: true,
+		}, {
+			Name: "no_head",
+			Help: `Don't use HEAD requests to find file sizes in dir listing.
+
+Normally rclone does a HEAD request for each file in a directory
+listing to find its size and modification time and to check it
+really exists. If this is set the sizes and times of the files won't
+be known until they are read.`,
+			Default:  false,
+			Advanced: true,
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	Endpoint string `config:"url"`
+	User     string `config:"user"`
+	Pass     string `config:"pass"`
+	NoHead   bool   `config:"no_head"`
+}
+
+// Fs represents a maven2 repository served over HTTP
+type Fs struct {
+	name        string         // name of this remote
+	root        string         // the path we are working on if any
+	opt         Options        // parsed config options
+	ci          *fs.ConfigInfo // global config
+	features    *fs.Features   // optional features
+	srv         *rest.Client   // the connection to the server
+	pacer       *fs.Pacer      // pacer for API calls
+	endpoint    *url.URL       // parsed URL of the repository
+	endpointURL string         // URL of the repository ending in /
+}
+
+// Object describes a file in the repository
+type Object struct {
+	fs          *Fs       // what this object is part of
+	remote      string    // The remote path
+	size        int64     // size of the object or -1 if unknown
+	modTime     time.Time // modification time on the server
+	contentType string    // Content-Type of the object
+	sha1        string    // SHA-1 of the object if read
+	md5         string    // MD5 of the object if read
+}
+
+// ------------------------------------------------------------
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	return f.endpointURL + f.root
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// url returns the URL of remote
+func (f *Fs) url(remote string) string {
+	return f.endpointURL + rest.URLPathEscape(path.Join(f.root, remote))
+}
+
+// retryErrorCodes is a slice of error codes that we will retry
+var retryErrorCodes = []int{
+	429, // Too Many Requests.
+	500, // Internal Server Error
+	502, // Bad Gateway
+	503, // Service Unavailable
+	504, // Gateway Timeout
+	509, // Bandwidth Limit Exceeded
+}
+
+// shouldRetry returns a boolean as to whether this resp and err
+// deserve to be retried.  It returns the err as a convenience
+func shouldRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if fserrors.ContextError(ctx, &err) {
+		return false, err
+	}
+	return fserrors.ShouldRetry(err) || fserrors.ShouldRetryHTTP(resp, retryErrorCodes), err
+}
+
+// errorHandler turns a non 2xx response into an error
+//
+// The body is usually an HTML page so it isn't included.
+func errorHandler(resp *http.Response) error {
+	_ = resp.Body.Close()
+	return errors.Errorf("HTTP error %d: %s", resp.StatusCode, resp.Status)
+}
+
+// isMissing returns true if resp indicates there is nothing at the URL
+//
+// Some repositories return 403 rather than 404 for paths they don't
+// serve.
+func isMissing(resp *http.Response) bool {
+	return resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden)
+}
+
+// NewFs constructs an Fs from the path
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	// Parse config into Options struct
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(opt.Endpoint, "/") {
+		opt.Endpoint += "/"
+	}
+	endpoint, err := url.Parse(opt.Endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't parse url %q", opt.Endpoint)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, errors.Errorf("url %q must start with http:// or https://", opt.Endpoint)
+	}
+	if opt.Pass != "" {
+		opt.Pass, err = obscure.Reveal(opt.Pass)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't decrypt password")
+		}
+	}
+
+	f := &Fs{
+		name:        name,
+		root:        strings.Trim(root, "/"),
+		opt:         *opt,
+		ci:          fs.GetConfig(ctx),
+		srv:         rest.NewClient(fshttp.NewClient(ctx)),
+		pacer:       fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
+		endpoint:    endpoint,
+		endpointURL: endpoint.String(),
+	}
+	f.features = (&fs.Features{
+		ReadMimeType: true,
+	}).Fill(ctx, f)
+	f.srv.SetErrorHandler(errorHandler)
+	if opt.User != "" {
+		f.srv.SetUserPass(opt.User, opt.Pass)
+	}
+
+	if f.root != "" {
+		// Check to see if the root is actually an existing file
+		oldRoot := f.root
+		newRoot, leaf := path.Split(oldRoot)
+		f.root = strings.Trim(newRoot, "/")
+		_, err := f.NewObject(ctx, leaf)
+		if err != nil {
+			// File doesn't exist so return old f
+			f.root = oldRoot
+			return f, nil
+		}
+		// return an error with an fs which points to the parent
+		return f, fs.ErrorIsFile
+	}
+	return f, nil
+}
+
+// NewObject finds the Object at remote.  If it can't be found
+// it returns the error fs.ErrorObjectNotFound.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	o := &Object{
+		fs:     f,
+		remote: remote,
+	}
+	err := o.stat(ctx)
+	if err == fs.ErrorNotAFile {
+		return nil, fs.ErrorObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// get reads the URL u returning the response
+//
+// It returns nil with no error if there is nothing at the URL.
+func (f *Fs) get(ctx context.Context, u string) (resp *http.Response, err error) {
+	opts := rest.Opts{
+		Method:  "GET",
+		RootURL: u,
+	}
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if isMissing(resp) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// parseName turns a link found in the index at base into the name of
+// an entry in the directory, returning "" if it isn't one
+//
+// Names of directories end in /.
+func parseName(base *url.URL, link string) string {
+	u, err := rest.URLJoin(base, link)
+	if err != nil || u.RawQuery != "" || u.Host != base.Host || u.Scheme != base.Scheme {
+		return ""
+	}
+	if !strings.HasPrefix(u.Path, base.Path) {
+		return ""
+	}
+	name := u.Path[len(base.Path):]
+	// we are looking for a single level directory
+	if slash := strings.Index(name, "/"); slash >= 0 && slash != len(name)-1 {
+		return ""
+	}
+	return name
+}
+
+// parseIndex returns the names of the entries found in the HTML index
+// in at base
+func parseIndex(base *url.URL, in io.Reader) (names []string, err error) {
+	doc, err := html.Parse(in)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]struct{}{}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			for _, a := range n.Attr {
+				if a.Key != "href" {
+					continue
+				}
+				name := parseName(base, a.Val)
+				if _, found := seen[name]; name != "" && !found {
+					names = append(names, name)
+					seen[name] = struct{}{}
+				}
+				break
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return names, nil
+}
+
+// readIndex reads the HTML index of dir
+//
+// It returns errNoIndex if the server doesn't have one.
+func (f *Fs) readIndex(ctx context.Context, dir string) (names []string, err error) {
+	u := f.url(dir)
+	if !strings.HasSuffix(u, "/") {
+		u += "/"
+	}
+	base, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.get(ctx, u)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read directory index")
+	}
+	if resp == nil {
+		return nil, errNoIndex
+	}
+	defer fs.CheckClose(resp.Body, &err)
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" {
+		return nil, errNoIndex
+	}
+	// use the final URL in case of redirects
+	if resp.Request != nil && resp.Request.URL != nil {
+		base = resp.Request.URL
+	}
+	names, err = parseIndex(base, resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse directory index")
+	}
+	return names, nil
+}
+
+// readMetadata reads the maven-metadata.xml file in dir
+//
+// It returns nil with no error if there isn't one.
+func (f *Fs) readMetadata(ctx context.Context, dir string) (metadata *api.Metadata, err error) {
+	resp, err := f.get(ctx, f.url(path.Join(dir, metadataName)))
+	if err != nil || resp == nil {
+		return nil, err
+	}
+	defer fs.CheckClose(resp.Body, &err)
+	metadata = new(api.Metadata)
+	err = xml.NewDecoder(resp.Body).Decode(metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse "+metadataName)
+	}
+	return metadata, nil
+}
+
+// metadataEntries returns the names of the entries in dir described by
+// its maven-metadata.xml
+//
+// If there isn't any metadata the directory may be a release version
+// so the POM and JAR of the artifact are tried.
+func (f *Fs) metadataEntries(ctx context.Context, dir string) (names []string, err error) {
+	metadata, err := f.readMetadata(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	if metadata == nil {
+		absDir := path.Join(f.root, dir)
+		artifactDir, version := path.Split(absDir)
+		artifactID := path.Base(artifactDir)
+		if version == "" || artifactID == "." || artifactID == "/" {
+			return nil, nil
+		}
+		for _, extension := range []string{"pom", "jar"} {
+			m := artifact.Maven{ArtifactID: artifactID, Version: version, Extension: extension}
+			names = append(names, m.FileName())
+		}
+		return names, nil
+	}
+	names = append(names, metadataName)
+	for _, version := range metadata.Versioning.Versions {
+		names = append(names, version+"/")
+	}
+	for _, plugin := range metadata.Plugins {
+		names = append(names, plugin.ArtifactID+"/")
+	}
+	for _, file := range metadata.Versioning.SnapshotVersions {
+		m := artifact.Maven{
+			ArtifactID:  metadata.ArtifactID,
+			Version:     metadata.Version,
+			FileVersion: file.Value,
+			Classifier:  file.Classifier,
+			Extension:   file.Extension,
+		}
+		names = append(names, m.FileName())
+	}
+	return names, nil
+}
+
+// List the objects and directories in dir into entries.  The
+// entries can be returned in any order but should be for a
+// complete directory.
+//
+// dir should be "" to list the root, and should not have
+// trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	names, err := f.readIndex(ctx, dir)
+	if err == errNoIndex {
+		names, err = f.metadataEntries(ctx, dir)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing %q", dir)
+	}
+	var (
+		entriesMu sync.Mutex // to protect entries
+		wg        sync.WaitGroup
+		checkers  = f.ci.Checkers
+		in        = make(chan string, checkers)
+	)
+	add := func(entry fs.DirEntry) {
+		entriesMu.Lock()
+		entries = append(entries, entry)
+		entriesMu.Unlock()
+	}
+	for i := 0; i < checkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for remote := range in {
+				o := &Object{
+					fs:     f,
+					remote: remote,
+				}
+				switch err := o.stat(ctx); err {
+				case nil:
+					add(o)
+				case fs.ErrorNotAFile:
+					add(fs.NewDir(remote, timeUnset))
+				case fs.ErrorObjectNotFound:
+				default:
+					fs.Debugf(remote, "skipping because of error: %v", err)
+				}
+			}
+		}()
+	}
+	for _, name := range names {
+		remote := path.Join(dir, strings.TrimRight(name, "/"))
+		if strings.HasSuffix(name, "/") {
+			add(fs.NewDir(remote, timeUnset))
+		} else {
+			in <- remote
+		}
+	}
+	close(in)
+	wg.Wait()
+	if len(entries) == 0 && dir != "" {
+		return nil, fs.ErrorDirNotFound
+	}
+	return entries, nil
+}
+
+// Put the object
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	return nil, errorReadOnly
+}
+
+// Mkdir creates the directory if it doesn't exist
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	return errorReadOnly
+}
+
+// Rmdir deletes the directory if it is empty
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	return errorReadOnly
+}
+
+// Precision of the ModTimes in this Fs
+func (f *Fs) Precision() time.Duration {
+	return time.Second
+}
+
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() hash.Set {
+	return hash.NewHashSet(hash.MD5, hash.SHA1)
+}
+
+// ------------------------------------------------------------
+
+// Fs returns the parent Fs
+func (o *Object) Fs() fs.Info {
+	return o.fs
+}
+
+// Return a string version
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// Remote returns the remote path
+func (o *Object) Remote() string {
+	return o.remote
+}
+
+// readChecksum reads the checksum file with extension ext stored
+// next to the object, returning "" if there isn't one
+func (o *Object) readChecksum(ctx context.Context, ext string) (checksum string, err error) {
+	resp, err := o.fs.get(ctx, o.fs.url(o.remote+ext))
+	if err != nil || resp == nil {
+		return "", err
+	}
+	defer fs.CheckClose(resp.Body, &err)
+	return artifact.ReadChecksum(resp.Body)
+}
+
+// Hash returns the MD5 or SHA-1 of an object returning a lowercase hex string
+//
+// These are read from the .md5 and .sha1 files stored next to the
+// object.
+func (o *Object) Hash(ctx context.Context, t hash.Type) (_ string, err error) {
+	var checksum *string
+	switch t {
+	case hash.SHA1:
+		checksum = &o.sha1
+	case hash.MD5:
+		checksum = &o.md5
+	default:
+		return "", hash.ErrUnsupported
+	}
+	if *checksum == "" {
+		*checksum, err = o.readChecksum(ctx, artifact.ChecksumExtension(t))
+	}
+	return *checksum, err
+}
+
+// Size returns the size of an object in bytes
+func (o *Object) Size() int64 {
+	return o.size
+}
+
+// stat reads the size, modification time and content type of the
+// object
+//
+// It returns fs.ErrorNotAFile if the object is a directory index.
+func (o *Object) stat(ctx context.Context) error {
+	if o.fs.opt.NoHead {
+		o.size = -1
+		o.modTime = timeUnset
+		o.contentType = fs.MimeType(ctx, o)
+		return nil
+	}
+	opts := rest.Opts{
+		Method:     "HEAD",
+		RootURL:    o.fs.url(o.remote),
+		NoResponse: true,
+	}
+	var resp *http.Response
+	var err error
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if isMissing(resp) {
+			return fs.ErrorObjectNotFound
+		}
+		return errors.Wrap(err, "failed to stat")
+	}
+	o.contentType = resp.Header.Get("Content-Type")
+	// maven repositories don't hold HTML files so this must be the
+	// index of a directory
+	if mediaType, _, _ := mime.ParseMediaType(o.contentType); mediaType == "text/html" {
+		return fs.ErrorNotAFile
+	}
+	o.size = resp.ContentLength
+	o.modTime, err = http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		o.modTime = timeUnset
+	}
+	return nil
+}
+
+// ModTime returns the modification time of the object
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	return o.modTime
+}
+
+// SetModTime sets the modification time of the object
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	return errorReadOnly
+}
+
+// Storable returns a boolean showing whether this object storable
+func (o *Object) Storable() bool {
+	return true
+}
+
+// Open an object for read
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
+	fs.FixRangeOption(options, o.size)
+	opts := rest.Opts{
+		Method:  "GET",
+		RootURL: o.fs.url(o.remote),
+		Options: options,
+	}
+	var resp *http.Response
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, err
+}
+
+// Update the object with the contents of the io.Reader, modTime and size
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	return errorReadOnly
+}
+
+// Remove an object
+func (o *Object) Remove(ctx context.Context) error {
+	return errorReadOnly
+}
+
+// MimeType of an Object if known, "" otherwise
+func (o *Object) MimeType(ctx context.Context) string {
+	return o.contentType
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs        = &Fs{}
+	_ fs.Object    = &Object{}
+	_ fs.MimeTyper = &Object{}
+)
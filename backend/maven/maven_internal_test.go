@@ -0,0 +1,201 @@
+package maven
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFiles are the files served by the test server
+var testFiles = map[string]string{
+	"/repo/": `<html><body>
+<a href="../">../</a>
+<a href="org/">org/</a>
+<a href="README.txt">README.txt</a>
+<a href="?C=N;O=D">Name</a>
+<a href="http://example.com/elsewhere">elsewhere</a>
+</body></html>`,
+	"/repo/README.txt": "hello",
+	"/repo/org/example/app/maven-metadata.xml": `<metadata>
+  <groupId>org.example</groupId>
+  <artifactId>app</artifactId>
+  <versioning>
+    <versions>
+      <version>1.0</version>
+      <version>1.1-SNAPSHOT</version>
+    </versions>
+  </versioning>
+</metadata>`,
+	"/repo/org/example/app/1.0/app-1.0.pom":      "<project/>",
+	"/repo/org/example/app/1.0/app-1.0.jar":      "jar",
+	"/repo/org/example/app/1.0/app-1.0.jar.sha1": "A9993E364706816ABA3E25717850C26C9CD0D89D  app-1.0.jar",
+	"/repo/org/example/app/1.1-SNAPSHOT/maven-metadata.xml": `<metadata>
+  <groupId>org.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.1-SNAPSHOT</version>
+  <versioning>
+    <snapshotVersions>
+      <snapshotVersion>
+        <extension>jar</extension>
+        <value>1.1-20210101.120000-1</value>
+      </snapshotVersion>
+      <snapshotVersion>
+        <classifier>sources</classifier>
+        <extension>jar</extension>
+        <value>1.1-20210101.120000-1</value>
+      </snapshotVersion>
+    </snapshotVersions>
+  </versioning>
+</metadata>`,
+	"/repo/org/example/app/1.1-SNAPSHOT/app-1.1-20210101.120000-1.jar":         "jar",
+	"/repo/org/example/app/1.1-SNAPSHOT/app-1.1-20210101.120000-1-sources.jar": "sources",
+}
+
+// modTime is the Last-Modified time of all the test files
+var modTime = time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+
+// prepare starts a test server and returns an Fs using it
+func prepare(t *testing.T) (fs.Fs, func()) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := testFiles[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		contentType := "application/octet-stream"
+		if r.URL.Path[len(r.URL.Path)-1] == '/' {
+			contentType = "text/html; charset=utf-8"
+		}
+		w.Header().Set("Content-Type", contentType)
+		http.ServeContent(w, r, "", modTime, strings.NewReader(content))
+	})
+	ts := httptest.NewServer(handler)
+	f, err := NewFs(context.Background(), "TestMaven", "", configmap.Simple{
+		"url": ts.URL + "/repo",
+	})
+	require.NoError(t, err)
+	return f, ts.Close
+}
+
+// listNames lists dir returning the sorted remotes
+func listNames(t *testing.T, f fs.Fs, dir string) []string {
+	entries, err := f.List(context.Background(), dir)
+	require.NoError(t, err, dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Remote())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestParseName(t *testing.T) {
+	base, err := url.Parse("https://repo.example.com/maven2/org/")
+	require.NoError(t, err)
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{"example/", "example/"},
+		{"https://repo.example.com/maven2/org/example/", "example/"},
+		{"maven-metadata.xml", "maven-metadata.xml"},
+		{"../", ""},
+		{"example/app/", ""},
+		{"?C=N;O=D", ""},
+		{"https://other.example.com/maven2/org/example/", ""},
+	} {
+		assert.Equal(t, test.want, parseName(base, test.in), test.in)
+	}
+}
+
+func TestList(t *testing.T) {
+	f, tidy := prepare(t)
+	defer tidy()
+
+	// from the HTML index
+	assert.Equal(t, []string{"README.txt", "org"}, listNames(t, f, ""))
+	// from the maven-metadata.xml
+	assert.Equal(t, []string{"org/example/app/1.0", "org/example/app/1.1-SNAPSHOT", "org/example/app/maven-metadata.xml"}, listNames(t, f, "org/example/app"))
+	// probing the release files
+	assert.Equal(t, []string{"org/example/app/1.0/app-1.0.jar", "org/example/app/1.0/app-1.0.pom"}, listNames(t, f, "org/example/app/1.0"))
+	// from the snapshot maven-metadata.xml
+	assert.Equal(t, []string{
+		"org/example/app/1.1-SNAPSHOT/app-1.1-20210101.120000-1-sources.jar",
+		"org/example/app/1.1-SNAPSHOT/app-1.1-20210101.120000-1.jar",
+		"org/example/app/1.1-SNAPSHOT/maven-metadata.xml",
+	}, listNames(t, f, "org/example/app/1.1-SNAPSHOT"))
+
+	_, err := f.List(context.Background(), "org/example/potato")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+}
+
+func TestObject(t *testing.T) {
+	f, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	o, err := f.NewObject(ctx, "org/example/app/1.0/app-1.0.jar")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), o.Size())
+	assert.Equal(t, 2006, o.ModTime(ctx).Year())
+
+	sha1, err := o.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, "a9993e364706816aba3e25717850c26c9cd0d89d", sha1)
+	md5, err := o.Hash(ctx, hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, "", md5)
+
+	_, err = f.NewObject(ctx, "org/example/app/1.0/app-1.0.war")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+}
+
+func TestListModTime(t *testing.T) {
+	f, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	entries, err := f.List(ctx, "org/example/app/1.0")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(entries))
+	for _, entry := range entries {
+		o, ok := entry.(fs.Object)
+		require.True(t, ok, entry.Remote())
+		assert.Equal(t, modTime, o.ModTime(ctx).UTC(), entry.Remote())
+	}
+}
+
+func TestOpen(t *testing.T) {
+	f, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	o, err := f.NewObject(ctx, "org/example/app/1.1-SNAPSHOT/app-1.1-20210101.120000-1-sources.jar")
+	require.NoError(t, err)
+
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "sources", string(data))
+
+	in, err = o.Open(ctx, &fs.RangeOption{Start: 2, End: 4})
+	require.NoError(t, err)
+	data, err = ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "urc", string(data))
+}
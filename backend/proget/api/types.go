@@ -0,0 +1,53 @@
+// Package api has type definitions for the ProGet API
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Error is returned by ProGet when something goes wrong
+//
+// ProGet returns errors as plain text so the body is kept as the
+// message.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+// Error returns a string for the error and satisfies the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("proget error %d: %s", e.StatusCode, e.Message)
+}
+
+// Feed is a feed as returned by the feed management API
+type Feed struct {
+	Name     string `json:"name"`
+	FeedType string `json:"feedType"`
+	Active   bool   `json:"active"`
+}
+
+// IsAsset returns true if the feed is an asset directory
+func (f *Feed) IsAsset() bool {
+	return strings.EqualFold(f.FeedType, "asset")
+}
+
+// Item is a file or folder in an asset directory
+type Item struct {
+	Name     string    `json:"name"`
+	Parent   string    `json:"parent"` // path of the parent folder, only set in recursive listings
+	Type     string    `json:"type"`   // content type of the file, empty for folders
+	Size     int64     `json:"size"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+	MD5      string    `json:"md5"`
+	SHA1     string    `json:"sha1"`
+	SHA256   string    `json:"sha256"`
+	SHA512   string    `json:"sha512"`
+}
+
+// IsDir returns true if the item is a folder
+func (i *Item) IsDir() bool {
+	return i.Type == ""
+}
@@ -0,0 +1,726 @@
+// Package proget provides an interface to the asset directories of
+// the Inedo ProGet repository manager.
+package proget
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/proget/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/walk"
+	"github.com/rclone/rclone/lib/artifact"
+	"github.com/rclone/rclone/lib/bucket"
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+const (
+	minSleep      = 10 * time.Millisecond
+	maxSleep      = 2 * time.Second
+	decayConstant = 2 // bigger for slower decay, exponential
+)
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "proget",
+		Description: "Inedo ProGet",
+		NewFs:       NewFs,
+		Options: []fs.Option{{
+			Name:     "endpoint",
+			Help:     "URL of the ProGet server.",
+			Required: true,
+			Examples: []fs.OptionExample{{
+				Value: "https://proget.example.com",
+				Help:  "ProGet served from the root of the server",
+			}},
+		}, {
+			Name: "api_key",
+			Help: `API key.
+
+The key needs the "View/Download" and "Add/Repackage" permissions on
+the feeds used. To list the feeds at the root of the remote it needs
+access to the Feed Management API too.
+
+Leave blank for anonymous access.`,
+		}, {
+			Name:     config.ConfigEncoding,
+			Help:     config.ConfigEncodingHelp,
+			Advanced: true,
+			Default: (encoder.Display |
+				encoder.EncodeBackSlash |
+				encoder.EncodeInvalidUtf8),
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	Endpoint string               `config:"endpoint"`
+	APIKey   string               `config:"api_key"`
+	Enc      encoder.MultiEncoder `config:"encoding"`
+}
+
+// Fs represents the asset directories of a ProGet server
+type Fs struct {
+	name          string       // name of this remote
+	root          string       // the path we are working on if any
+	opt           Options      // parsed config options
+	features      *fs.Features // optional features
+	srv           *rest.Client // the connection to the server
+	pacer         *fs.Pacer    // pacer for API calls
+	rootBucket    string       // feed part of root (if any)
+	rootDirectory string       // directory part of root (if any)
+}
+
+// Object describes a file in an asset directory
+type Object struct {
+	fs          *Fs                // what this object is part of
+	remote      string             // The remote path
+	hasMetaData bool               // whether the info below has been read
+	size        int64              // size of the object
+	modTime     time.Time          // modification time on the server
+	checksums   artifact.Checksums // MD5 and SHA-1 of the object content
+	mimeType    string             // Content-Type of the object
+}
+
+// ------------------------------------------------------------
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	if f.rootBucket == "" {
+		return "ProGet root"
+	}
+	if f.rootDirectory == "" {
+		return fmt.Sprintf("ProGet feed %s", f.rootBucket)
+	}
+	return fmt.Sprintf("ProGet feed %s path %s", f.rootBucket, f.rootDirectory)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// parsePath parses a remote 'url'
+func parsePath(path string) (root string) {
+	root = strings.Trim(path, "/")
+	return
+}
+
+// split returns feed and feedPath from the rootRelativePath
+// relative to f.root
+func (f *Fs) split(rootRelativePath string) (feed, feedPath string) {
+	return bucket.Split(path.Join(f.root, rootRelativePath))
+}
+
+// split returns feed and feedPath from the object
+func (o *Object) split() (feed, feedPath string) {
+	return o.fs.split(o.remote)
+}
+
+// setRoot changes the root of the Fs
+func (f *Fs) setRoot(root string) {
+	f.root = parsePath(root)
+	f.rootBucket, f.rootDirectory = bucket.Split(f.root)
+}
+
+// endpointPath returns the URL path of feedPath in feed for the asset
+// directory endpoint called method, e.g. "content" or "dir"
+func (f *Fs) endpointPath(method, feed, feedPath string) string {
+	return rest.URLPathEscape(path.Join("/endpoints", feed, method, f.opt.Enc.FromStandardPath(feedPath)))
+}
+
+// retryErrorCodes is a slice of error codes that we will retry
+var retryErrorCodes = []int{
+	429, // Too Many Requests.
+	500, // Internal Server Error
+	502, // Bad Gateway
+	503, // Service Unavailable
+	504, // Gateway Timeout
+	509, // Bandwidth Limit Exceeded
+}
+
+// shouldRetry returns a boolean as to whether this resp and err
+// deserve to be retried.  It returns the err as a convenience
+func shouldRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if fserrors.ContextError(ctx, &err) {
+		return false, err
+	}
+	return fserrors.ShouldRetry(err) || fserrors.ShouldRetryHTTP(resp, retryErrorCodes), err
+}
+
+// errorHandler parses a non 2xx error response into an error
+func errorHandler(resp *http.Response) error {
+	body, err := rest.ReadBody(resp)
+	if err != nil {
+		fs.Debugf(nil, "Couldn't read error response: %v", err)
+	}
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		message = resp.Status
+	}
+	return &api.Error{
+		StatusCode: resp.StatusCode,
+		Message:    message,
+	}
+}
+
+// isNotFound returns true if resp indicates the item wasn't found
+func isNotFound(resp *http.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusNotFound
+}
+
+// NewFs constructs an Fs from the path, feed:path
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	// Parse config into Options struct
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Endpoint == "" {
+		return nil, errors.New("endpoint not set")
+	}
+	endpoint, err := url.Parse(opt.Endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't parse endpoint %q", opt.Endpoint)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, errors.Errorf("endpoint %q must start with http:// or https://", opt.Endpoint)
+	}
+
+	f := &Fs{
+		name:  name,
+		opt:   *opt,
+		srv:   rest.NewClient(fshttp.NewClient(ctx)).SetRoot(strings.TrimRight(opt.Endpoint, "/")),
+		pacer: fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
+	}
+	f.setRoot(root)
+	f.features = (&fs.Features{
+		ReadMimeType:            true,
+		WriteMimeType:           true,
+		CanHaveEmptyDirectories: true,
+		BucketBased:             true,
+		BucketBasedRootOK:       true,
+	}).Fill(ctx, f)
+	f.srv.SetErrorHandler(errorHandler)
+	if opt.APIKey != "" {
+		f.srv.SetHeader("X-ApiKey", opt.APIKey)
+	}
+
+	if f.rootBucket != "" && f.rootDirectory != "" {
+		// Check to see if the (feed,directory) is actually an existing file
+		oldRoot := f.root
+		newRoot, leaf := path.Split(oldRoot)
+		f.setRoot(newRoot)
+		_, err := f.NewObject(ctx, leaf)
+		if err != nil {
+			// File doesn't exist so return old f
+			f.setRoot(oldRoot)
+			return f, nil
+		}
+		// return an error with an fs which points to the parent
+		return f, fs.ErrorIsFile
+	}
+	return f, nil
+}
+
+// readItem reads the metadata for feedPath in feed
+func (f *Fs) readItem(ctx context.Context, feed, feedPath string) (item *api.Item, resp *http.Response, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   f.endpointPath("metadata", feed, feedPath),
+	}
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &item)
+		return shouldRetry(ctx, resp, err)
+	})
+	return item, resp, err
+}
+
+// Return an Object from a path
+//
+// If it can't be found it returns the error fs.ErrorObjectNotFound.
+func (f *Fs) newObjectWithInfo(ctx context.Context, remote string, item *api.Item) (fs.Object, error) {
+	o := &Object{
+		fs:     f,
+		remote: remote,
+	}
+	var err error
+	if item != nil {
+		err = o.setMetaData(item)
+	} else {
+		err = o.readMetaData(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// NewObject finds the Object at remote.  If it can't be found
+// it returns the error fs.ErrorObjectNotFound.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	return f.newObjectWithInfo(ctx, remote, nil)
+}
+
+// listFeeds lists the asset directory feeds as directories
+func (f *Fs) listFeeds(ctx context.Context) (entries fs.DirEntries, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   "/api/management/feeds/list",
+	}
+	var feeds []api.Feed
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &feeds)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list feeds")
+	}
+	for _, feed := range feeds {
+		if !feed.IsAsset() || !feed.Active {
+			continue
+		}
+		entries = append(entries, fs.NewDir(feed.Name, time.Time{}))
+	}
+	return entries, nil
+}
+
+// listFn is called from list to handle an item.
+type listFn func(remote string, item *api.Item) error
+
+// list the items in dir of feed, recursing if deep is set, calling
+// fn for each one. The remotes passed to fn are relative to dir.
+func (f *Fs) list(ctx context.Context, feed, directory string, deep bool, fn listFn) error {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   f.endpointPath("dir", feed, directory),
+		Parameters: url.Values{
+			"recursive": {fmt.Sprint(deep)},
+		},
+	}
+	var items []api.Item
+	var resp *http.Response
+	var err error
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &items)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if isNotFound(resp) {
+			return fs.ErrorDirNotFound
+		}
+		return errors.Wrap(err, "failed to list files")
+	}
+	prefix := f.opt.Enc.FromStandardPath(directory)
+	for i := range items {
+		item := &items[i]
+		// The parent is relative to the root of the feed
+		parent := strings.Trim(item.Parent, "/")
+		if parent != "" && prefix != "" {
+			if parent != prefix && !strings.HasPrefix(parent, prefix+"/") {
+				fs.Debugf(f, "Ignoring %q in %q outside %q", item.Name, parent, prefix)
+				continue
+			}
+			parent = strings.TrimPrefix(strings.TrimPrefix(parent, prefix), "/")
+		}
+		remote := f.opt.Enc.ToStandardPath(path.Join(parent, item.Name))
+		err = fn(remote, item)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// itemToDirEntry converts an item listed in dir into an fs.DirEntry
+func (f *Fs) itemToDirEntry(ctx context.Context, dir, remote string, item *api.Item) (fs.DirEntry, error) {
+	remote = path.Join(dir, remote)
+	if item.IsDir() {
+		return fs.NewDir(remote, item.Modified), nil
+	}
+	return f.newObjectWithInfo(ctx, remote, item)
+}
+
+// List the objects and directories in dir into entries.  The
+// entries can be returned in any order but should be for a
+// complete directory.
+//
+// dir should be "" to list the root, and should not have
+// trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	feed, directory := f.split(dir)
+	if feed == "" {
+		if directory != "" {
+			return nil, fs.ErrorListBucketRequired
+		}
+		return f.listFeeds(ctx)
+	}
+	err = f.list(ctx, feed, directory, false, func(remote string, item *api.Item) error {
+		entry, err := f.itemToDirEntry(ctx, dir, remote, item)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListR lists the objects and directories of the Fs starting
+// from dir recursively into out.
+//
+// dir should be "" to start from the root, and should not
+// have trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+//
+// It should call callback for each tranche of entries read.
+// These need not be returned in any particular order.  If
+// callback returns an error then the listing will stop
+// immediately.
+//
+// Don't implement this unless you have a more efficient way
+// of listing recursively than doing a directory traversal.
+func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (err error) {
+	feed, directory := f.split(dir)
+	list := walk.NewListRHelper(callback)
+	listR := func(feed, directory, prefix string) error {
+		return f.list(ctx, feed, directory, true, func(remote string, item *api.Item) error {
+			entry, err := f.itemToDirEntry(ctx, prefix, remote, item)
+			if err != nil {
+				return err
+			}
+			return list.Add(entry)
+		})
+	}
+	if feed == "" {
+		if directory != "" {
+			return fs.ErrorListBucketRequired
+		}
+		entries, err := f.listFeeds(ctx)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			err = list.Add(entry)
+			if err != nil {
+				return err
+			}
+			feed := entry.Remote()
+			err = listR(feed, "", feed)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err = listR(feed, directory, dir)
+		if err != nil {
+			return err
+		}
+	}
+	return list.Flush()
+}
+
+// Put the object into the feed
+//
+// Copy the reader in to the new object which is returned
+//
+// The new object may have been created if an error is returned
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	o := &Object{
+		fs:     f,
+		remote: src.Remote(),
+	}
+	return o, o.Update(ctx, in, src, options...)
+}
+
+// PutStream uploads to the remote path with the modTime given of indeterminate size
+func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	return f.Put(ctx, in, src, options...)
+}
+
+// Mkdir creates the directory if it doesn't exist
+//
+// Feeds can't be created so this returns an error if the feed
+// doesn't exist.
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	feed, feedPath := f.split(dir)
+	if feed == "" {
+		return nil
+	}
+	if feedPath == "" {
+		err := f.list(ctx, feed, "", false, func(string, *api.Item) error {
+			return nil
+		})
+		if err == fs.ErrorDirNotFound {
+			return errors.Errorf("feed %q not found - create it in ProGet first", feed)
+		}
+		return err
+	}
+	opts := rest.Opts{
+		Method:     "POST",
+		Path:       f.endpointPath("dir", feed, feedPath),
+		NoResponse: true,
+	}
+	return f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+}
+
+// deleteItem deletes the file or folder at feedPath in feed
+func (f *Fs) deleteItem(ctx context.Context, feed, feedPath string, recursive bool) error {
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   f.endpointPath("delete", feed, feedPath),
+		Parameters: url.Values{
+			"recursive": {fmt.Sprint(recursive)},
+		},
+		NoResponse: true,
+	}
+	var resp *http.Response
+	var err error
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil && isNotFound(resp) {
+		return fs.ErrorDirNotFound
+	}
+	return err
+}
+
+// Rmdir deletes the directory if it is empty
+//
+// Returns an error if it isn't empty
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	feed, feedPath := f.split(dir)
+	if feed == "" {
+		return nil
+	}
+	if feedPath == "" {
+		return errors.Errorf("can't remove feed %q", feed)
+	}
+	entries, err := f.List(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) != 0 {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	return f.deleteItem(ctx, feed, feedPath, false)
+}
+
+// Purge deletes all the files and directories in dir
+func (f *Fs) Purge(ctx context.Context, dir string) error {
+	feed, feedPath := f.split(dir)
+	if feedPath == "" {
+		// Don't empty whole feeds in one go
+		return fs.ErrorCantPurge
+	}
+	return f.deleteItem(ctx, feed, feedPath, true)
+}
+
+// Precision of the ModTimes in this Fs
+func (f *Fs) Precision() time.Duration {
+	return fs.ModTimeNotSupported
+}
+
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() hash.Set {
+	return artifact.ChecksumTypes()
+}
+
+// ------------------------------------------------------------
+
+// Fs returns the parent Fs
+func (o *Object) Fs() fs.Info {
+	return o.fs
+}
+
+// Return a string version
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// Remote returns the remote path
+func (o *Object) Remote() string {
+	return o.remote
+}
+
+// Hash returns the MD5 or SHA-1 of an object returning a lowercase hex string
+func (o *Object) Hash(ctx context.Context, t hash.Type) (string, error) {
+	return o.checksums.Get(t)
+}
+
+// Size returns the size of an object in bytes
+func (o *Object) Size() int64 {
+	return o.size
+}
+
+// setMetaData sets the metadata from item
+func (o *Object) setMetaData(item *api.Item) error {
+	if item.IsDir() {
+		return fs.ErrorObjectNotFound
+	}
+	o.hasMetaData = true
+	o.size = item.Size
+	o.modTime = item.Modified
+	o.checksums = artifact.NewChecksums(item.MD5, item.SHA1)
+	o.mimeType = item.Type
+	return nil
+}
+
+// readMetaData gets the metadata if it hasn't already been fetched
+//
+// it also sets the info
+func (o *Object) readMetaData(ctx context.Context) error {
+	if o.hasMetaData {
+		return nil
+	}
+	feed, feedPath := o.split()
+	if feed == "" || feedPath == "" {
+		return fs.ErrorObjectNotFound
+	}
+	item, resp, err := o.fs.readItem(ctx, feed, feedPath)
+	if err != nil {
+		if isNotFound(resp) {
+			return fs.ErrorObjectNotFound
+		}
+		return err
+	}
+	return o.setMetaData(item)
+}
+
+// ModTime returns the modification time of the object
+//
+// This is the time the file was last uploaded to ProGet
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	return o.modTime
+}
+
+// SetModTime sets the modification time of the object
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	return fs.ErrorCantSetModTime
+}
+
+// Storable returns a boolean showing whether this object storable
+func (o *Object) Storable() bool {
+	return true
+}
+
+// Open an object for read
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
+	feed, feedPath := o.split()
+	fs.FixRangeOption(options, o.size)
+	opts := rest.Opts{
+		Method:  "GET",
+		Path:    o.fs.endpointPath("content", feed, feedPath),
+		Options: options,
+	}
+	var resp *http.Response
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, err
+}
+
+// Update the object with the contents of the io.Reader, modTime and size
+//
+// The new object may have been created if an error is returned
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (err error) {
+	feed, feedPath := o.split()
+	if feed == "" || feedPath == "" {
+		return errors.New("can't upload files to the root")
+	}
+	size := src.Size()
+	opts := rest.Opts{
+		Method:      "PUT",
+		Path:        o.fs.endpointPath("content", feed, feedPath),
+		Body:        in,
+		ContentType: fs.MimeType(ctx, src),
+		Options:     options,
+		NoResponse:  true,
+	}
+	if size >= 0 {
+		opts.ContentLength = &size
+	}
+	err = o.fs.pacer.CallNoRetry(func() (bool, error) {
+		resp, err := o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "upload failed")
+	}
+	// ProGet doesn't return the metadata of the upload so read it
+	o.hasMetaData = false
+	return o.readMetaData(ctx)
+}
+
+// Remove an object
+func (o *Object) Remove(ctx context.Context) error {
+	feed, feedPath := o.split()
+	err := o.fs.deleteItem(ctx, feed, feedPath, false)
+	if err == fs.ErrorDirNotFound {
+		return fs.ErrorObjectNotFound
+	}
+	return err
+}
+
+// MimeType of an Object if known, "" otherwise
+func (o *Object) MimeType(ctx context.Context) string {
+	return o.mimeType
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs          = &Fs{}
+	_ fs.PutStreamer = &Fs{}
+	_ fs.Purger      = &Fs{}
+	_ fs.ListRer     = &Fs{}
+	_ fs.Object      = &Object{}
+	_ fs.MimeTyper   = &Object{}
+)
@@ -0,0 +1,146 @@
+package proget
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testItem = `{"name":"app-1.0.zip","type":"application/zip","size":5,"modified":"2021-01-02T03:04:05Z","md5":"5D41402ABC4B2A76B9719D911017C592","sha1":"aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"}`
+
+// prepare starts a test server and returns an Fs using it
+//
+// The server has an asset feed "assets" holding
+// releases/app-1.0.zip and releases/old/app-0.9.zip
+func prepare(t *testing.T) (*Fs, *[]string, func()) {
+	var calls []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-ApiKey"))
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/management/feeds/list":
+			_, _ = w.Write([]byte(`[{"name":"assets","feedType":"asset","active":true},{"name":"nuget","feedType":"nuget","active":true},{"name":"old","feedType":"asset","active":false}]`))
+		case "GET /endpoints/assets/dir/releases":
+			if r.URL.Query().Get("recursive") == "true" {
+				_, _ = w.Write([]byte(`[{"name":"old","parent":"releases"},` + testItem + `,{"name":"app-0.9.zip","parent":"releases/old","type":"application/zip","size":3}]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[{"name":"old"},` + testItem + `]`))
+		case "GET /endpoints/assets/metadata/releases/app-1.0.zip":
+			_, _ = w.Write([]byte(testItem))
+		case "GET /endpoints/assets/metadata/releases":
+			_, _ = w.Write([]byte(`{"name":"releases"}`))
+		case "GET /endpoints/assets/content/releases/app-1.0.zip":
+			w.Header().Set("Content-Type", "application/zip")
+			_, _ = w.Write([]byte("hello"))
+		case "PUT /endpoints/assets/content/releases/app-1.0.zip":
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "hello", string(body))
+			assert.Equal(t, "application/zip", r.Header.Get("Content-Type"))
+			w.WriteHeader(http.StatusCreated)
+		case "POST /endpoints/assets/delete/releases/old":
+			assert.Equal(t, "true", r.URL.Query().Get("recursive"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("Not found"))
+		}
+	})
+	ts := httptest.NewServer(handler)
+	f, err := NewFs(context.Background(), "TestProGet", "", configmap.Simple{
+		"endpoint": ts.URL,
+		"api_key":  "secret",
+	})
+	require.NoError(t, err)
+	return f.(*Fs), &calls, ts.Close
+}
+
+// listNames lists dir returning the sorted remotes
+func listNames(t *testing.T, f fs.Fs, dir string) []string {
+	entries, err := f.List(context.Background(), dir)
+	require.NoError(t, err, dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Remote())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestList(t *testing.T) {
+	f, _, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	assert.Equal(t, []string{"assets"}, listNames(t, f, ""))
+	assert.Equal(t, []string{"assets/releases/app-1.0.zip", "assets/releases/old"}, listNames(t, f, "assets/releases"))
+
+	_, err := f.List(ctx, "assets/potato")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+
+	var names []string
+	err = f.ListR(ctx, "assets/releases", func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			names = append(names, entry.Remote())
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	sort.Strings(names)
+	assert.Equal(t, []string{"assets/releases/app-1.0.zip", "assets/releases/old", "assets/releases/old/app-0.9.zip"}, names)
+}
+
+func TestObject(t *testing.T) {
+	f, _, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	o, err := f.NewObject(ctx, "assets/releases/app-1.0.zip")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), o.Size())
+	assert.Equal(t, 2021, o.ModTime(ctx).Year())
+	md5, err := o.Hash(ctx, hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", md5)
+	assert.Equal(t, "application/zip", o.(fs.MimeTyper).MimeType(ctx))
+
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "hello", string(data))
+
+	_, err = f.NewObject(ctx, "assets/releases")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+	_, err = f.NewObject(ctx, "assets/releases/potato.zip")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+}
+
+func TestPutAndPurge(t *testing.T) {
+	f, calls, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	src := object.NewStaticObjectInfo("assets/releases/app-1.0.zip", time.Now(), 5, true, nil, nil)
+	o, err := f.Put(ctx, strings.NewReader("hello"), src)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), o.Size())
+
+	require.NoError(t, f.Purge(ctx, "assets/releases/old"))
+	assert.Equal(t, fs.ErrorCantPurge, f.Purge(ctx, "assets"))
+	assert.Contains(t, *calls, "POST /endpoints/assets/delete/releases/old")
+}
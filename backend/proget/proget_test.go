@@ -0,0 +1,17 @@
+// Test ProGet filesystem interface
+package proget_test
+
+import (
+	"testing"
+
+	"github.com/rclone/rclone/backend/proget"
+	"github.com/rclone/rclone/fstest/fstests"
+)
+
+// TestIntegration runs integration tests against the remote
+func TestIntegration(t *testing.T) {
+	fstests.Run(t, &fstests.Opt{
+		RemoteName: "TestProGet:rclone-test",
+		NilObject:  (*proget.Object)(nil),
+	})
+}
@@ -0,0 +1,130 @@
+// Package api has type definitions for the Pulp 3 REST API
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Error is returned by the API when something goes wrong
+//
+// Validation errors are returned as an object of field names to
+// messages so the body is kept raw if there is no detail.
+type Error struct {
+	StatusCode int             `json:"-"`
+	Detail     string          `json:"detail"`
+	Raw        json.RawMessage `json:"-"`
+}
+
+// Error returns a string for the error and satisfies the error interface
+func (e *Error) Error() string {
+	out := fmt.Sprintf("pulp error %d", e.StatusCode)
+	if e.Detail != "" {
+		out += ": " + e.Detail
+	} else if len(e.Raw) > 0 {
+		out += ": " + string(e.Raw)
+	}
+	return out
+}
+
+// Page is a page of a paginated list
+//
+// Next is the URL of the next page or empty for the last page.
+type Page struct {
+	Count   int             `json:"count"`
+	Next    string          `json:"next"`
+	Results json.RawMessage `json:"results"`
+}
+
+// Repository is a file repository
+type Repository struct {
+	PulpHref          string    `json:"pulp_href"`
+	PulpCreated       time.Time `json:"pulp_created"`
+	Name              string    `json:"name"`
+	LatestVersionHref string    `json:"latest_version_href"`
+}
+
+// Distribution serves a repository from the content app
+type Distribution struct {
+	PulpHref   string `json:"pulp_href"`
+	Name       string `json:"name"`
+	BasePath   string `json:"base_path"`
+	BaseURL    string `json:"base_url"`
+	Repository string `json:"repository"`
+}
+
+// FileContent is a file content unit
+type FileContent struct {
+	PulpHref     string    `json:"pulp_href"`
+	PulpCreated  time.Time `json:"pulp_created"`
+	RelativePath string    `json:"relative_path"`
+	Artifact     string    `json:"artifact"`
+	SHA256       string    `json:"sha256"`
+}
+
+// CreateFileContent is the request to create a file content unit
+type CreateFileContent struct {
+	RelativePath string `json:"relative_path"`
+	Artifact     string `json:"artifact"`
+}
+
+// Artifact is a stored file
+//
+// The checksums which aren't allowed by the server are null.
+type Artifact struct {
+	PulpHref string `json:"pulp_href"`
+	Size     int64  `json:"size"`
+	MD5      string `json:"md5"`
+	SHA1     string `json:"sha1"`
+	SHA256   string `json:"sha256"`
+}
+
+// CreateUpload is the request to start a chunked upload
+type CreateUpload struct {
+	Size int64 `json:"size"`
+}
+
+// Upload is a chunked upload in progress
+type Upload struct {
+	PulpHref string `json:"pulp_href"`
+	Size     int64  `json:"size"`
+}
+
+// CommitUpload is the request to turn an upload into an artifact
+type CommitUpload struct {
+	SHA256 string `json:"sha256"`
+}
+
+// ModifyRepository is the request to add or remove content units
+type ModifyRepository struct {
+	AddContentUnits    []string `json:"add_content_units,omitempty"`
+	RemoveContentUnits []string `json:"remove_content_units,omitempty"`
+}
+
+// AsyncOperation is returned by calls which start a task
+type AsyncOperation struct {
+	Task string `json:"task"`
+}
+
+// Task states
+const (
+	TaskWaiting   = "waiting"
+	TaskRunning   = "running"
+	TaskCompleted = "completed"
+	TaskFailed    = "failed"
+	TaskCanceled  = "canceled"
+)
+
+// TaskError describes why a task failed
+type TaskError struct {
+	Description string `json:"description"`
+}
+
+// Task is a background task on the server
+type Task struct {
+	PulpHref         string     `json:"pulp_href"`
+	State            string     `json:"state"`
+	CreatedResources []string   `json:"created_resources"`
+	Error            *TaskError `json:"error"`
+}
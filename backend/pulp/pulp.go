@@ -0,0 +1,1160 @@
+// Package pulp provides an interface to the file repositories of a
+// Pulp 3 content server.
+package pulp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/pulp/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/walk"
+	"github.com/rclone/rclone/lib/artifact"
+	"github.com/rclone/rclone/lib/bucket"
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+const (
+	minSleep         = 10 * time.Millisecond
+	maxSleep         = 2 * time.Second
+	decayConstant    = 2 // bigger for slower decay, exponential
+	apiPath          = "/pulp/api/v3"
+	defaultChunkSize = 6 * fs.Mebi
+	taskPollInterval = 500 * time.Millisecond
+)
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "pulp",
+		Description: "Pulp 3 content server",
+		NewFs:       NewFs,
+		Options: []fs.Option{{
+			Name:     "endpoint",
+			Help:     "URL of the Pulp API server, without the /pulp/api/v3 path.",
+			Required: true,
+			Examples: []fs.OptionExample{{
+				Value: "https://pulp.example.com",
+				Help:  "Pulp served from the root of the server",
+			}},
+		}, {
+			Name: "user",
+			Help: "User name.",
+		}, {
+			Name:       "pass",
+			Help:       "Password.",
+			IsPassword: true,
+		}, {
+			Name: "chunk_size",
+			Help: `Upload chunk size. Must fit in memory.
+
+Files are uploaded to Pulp in chunks of this size which are buffered
+in memory, so there might be "--transfers" chunks in memory at once.`,
+			Default:  defaultChunkSize,
+			Advanced: true,
+		}, {
+			Name:     config.ConfigEncoding,
+			Help:     config.ConfigEncodingHelp,
+			Advanced: true,
+			Default: (encoder.Display |
+				encoder.EncodeInvalidUtf8),
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	Endpoint  string               `config:"endpoint"`
+	User      string               `config:"user"`
+	Pass      string               `config:"pass"`
+	ChunkSize fs.SizeSuffix        `config:"chunk_size"`
+	Enc       encoder.MultiEncoder `config:"encoding"`
+}
+
+// Fs represents the file repositories of a Pulp server
+type Fs struct {
+	name          string            // name of this remote
+	root          string            // the path we are working on if any
+	opt           Options           // parsed config options
+	features      *fs.Features      // optional features
+	srv           *rest.Client      // the connection to the server
+	pacer         *fs.Pacer         // pacer for API calls
+	rootBucket    string            // repository part of root (if any)
+	rootDirectory string            // directory part of root (if any)
+	distMu        sync.Mutex        // protects distURLs
+	distURLs      map[string]string // content app URL for each repository href
+}
+
+// Object describes a file content unit in a repository
+type Object struct {
+	fs           *Fs                // what this object is part of
+	remote       string             // The remote path
+	contentHref  string             // href of the content unit
+	artifactHref string             // href of the artifact holding the data
+	size         int64              // size of the object
+	modTime      time.Time          // creation time of the content unit
+	checksums    artifact.Checksums // MD5 and SHA-1 of the object if allowed by the server
+}
+
+// ------------------------------------------------------------
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	if f.rootBucket == "" {
+		return "Pulp root"
+	}
+	if f.rootDirectory == "" {
+		return fmt.Sprintf("Pulp repository %s", f.rootBucket)
+	}
+	return fmt.Sprintf("Pulp repository %s path %s", f.rootBucket, f.rootDirectory)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// parsePath parses a remote 'url'
+func parsePath(path string) (root string) {
+	root = strings.Trim(path, "/")
+	return
+}
+
+// split returns repository and repositoryPath from the
+// rootRelativePath relative to f.root
+func (f *Fs) split(rootRelativePath string) (repo, repoPath string) {
+	repo, repoPath = bucket.Split(path.Join(f.root, rootRelativePath))
+	return f.opt.Enc.FromStandardName(repo), f.opt.Enc.FromStandardPath(repoPath)
+}
+
+// split returns repository and repositoryPath from the object
+func (o *Object) split() (repo, repoPath string) {
+	return o.fs.split(o.remote)
+}
+
+// setRoot changes the root of the Fs
+func (f *Fs) setRoot(root string) {
+	f.root = parsePath(root)
+	f.rootBucket, f.rootDirectory = bucket.Split(f.root)
+}
+
+// retryErrorCodes is a slice of error codes that we will retry
+var retryErrorCodes = []int{
+	429, // Too Many Requests.
+	500, // Internal Server Error
+	502, // Bad Gateway
+	503, // Service Unavailable
+	504, // Gateway Timeout
+	509, // Bandwidth Limit Exceeded
+}
+
+// shouldRetry returns a boolean as to whether this resp and err
+// deserve to be retried.  It returns the err as a convenience
+func shouldRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if fserrors.ContextError(ctx, &err) {
+		return false, err
+	}
+	return fserrors.ShouldRetry(err) || fserrors.ShouldRetryHTTP(resp, retryErrorCodes), err
+}
+
+// errorHandler parses a non 2xx error response into an error
+func errorHandler(resp *http.Response) error {
+	errResponse := &api.Error{
+		StatusCode: resp.StatusCode,
+	}
+	body, err := rest.ReadBody(resp)
+	if err != nil {
+		fs.Debugf(nil, "Couldn't read error response: %v", err)
+	}
+	if json.Unmarshal(body, errResponse) != nil || errResponse.Detail == "" {
+		errResponse.Raw = body
+	}
+	if errResponse.Detail == "" && len(errResponse.Raw) == 0 {
+		errResponse.Detail = resp.Status
+	}
+	return errResponse
+}
+
+// NewFs constructs an Fs from the path, repository:path
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	// Parse config into Options struct
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Endpoint == "" {
+		return nil, errors.New("endpoint not set")
+	}
+	endpoint, err := url.Parse(opt.Endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't parse endpoint %q", opt.Endpoint)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, errors.Errorf("endpoint %q must start with http:// or https://", opt.Endpoint)
+	}
+	if opt.ChunkSize <= 0 {
+		return nil, errors.New("chunk_size must be positive")
+	}
+	if opt.Pass != "" {
+		opt.Pass, err = obscure.Reveal(opt.Pass)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't decrypt password")
+		}
+	}
+
+	f := &Fs{
+		name:     name,
+		opt:      *opt,
+		srv:      rest.NewClient(fshttp.NewClient(ctx)).SetRoot(strings.TrimRight(opt.Endpoint, "/")),
+		pacer:    fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
+		distURLs: map[string]string{},
+	}
+	f.setRoot(root)
+	f.features = (&fs.Features{
+		BucketBased:       true,
+		BucketBasedRootOK: true,
+	}).Fill(ctx, f)
+	f.srv.SetErrorHandler(errorHandler)
+	if opt.User != "" {
+		f.srv.SetUserPass(opt.User, opt.Pass)
+	}
+
+	if f.rootBucket != "" && f.rootDirectory != "" {
+		// Check to see if the (repository,directory) is actually an existing file
+		oldRoot := f.root
+		newRoot, leaf := path.Split(oldRoot)
+		f.setRoot(newRoot)
+		_, err := f.NewObject(ctx, leaf)
+		if err != nil {
+			// File doesn't exist so return old f
+			f.setRoot(oldRoot)
+			return f, nil
+		}
+		// return an error with an fs which points to the parent
+		return f, fs.ErrorIsFile
+	}
+	return f, nil
+}
+
+// paginate calls the API with opts for every page of results, calling
+// fn with the results of each page
+func (f *Fs) paginate(ctx context.Context, opts *rest.Opts, fn func(results json.RawMessage) error) error {
+	return artifact.Paginate(func(next string) (string, error) {
+		if next != "" {
+			// the next link has all the parameters in already
+			opts.RootURL, opts.Path, opts.Parameters = next, "", nil
+		}
+		var page api.Page
+		var resp *http.Response
+		var err error
+		err = f.pacer.Call(func() (bool, error) {
+			resp, err = f.srv.CallJSON(ctx, opts, nil, &page)
+			return shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return "", err
+		}
+		return page.Next, fn(page.Results)
+	})
+}
+
+// getRepository reads the file repository called name
+func (f *Fs) getRepository(ctx context.Context, name string) (repo *api.Repository, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   apiPath + "/repositories/file/file/",
+		Parameters: url.Values{
+			"name": {name},
+		},
+	}
+	err = f.paginate(ctx, &opts, func(results json.RawMessage) error {
+		var repos []api.Repository
+		err := json.Unmarshal(results, &repos)
+		if err != nil {
+			return err
+		}
+		for i := range repos {
+			if repos[i].Name == name {
+				repo = &repos[i]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read repository")
+	}
+	if repo == nil {
+		return nil, fs.ErrorDirNotFound
+	}
+	return repo, nil
+}
+
+// listRepositories lists the file repositories as directories
+func (f *Fs) listRepositories(ctx context.Context) (entries fs.DirEntries, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   apiPath + "/repositories/file/file/",
+	}
+	err = f.paginate(ctx, &opts, func(results json.RawMessage) error {
+		var repos []api.Repository
+		err := json.Unmarshal(results, &repos)
+		if err != nil {
+			return err
+		}
+		for _, repo := range repos {
+			entries = append(entries, fs.NewDir(f.opt.Enc.ToStandardName(repo.Name), repo.PulpCreated).SetID(repo.PulpHref))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list repositories")
+	}
+	return entries, nil
+}
+
+// listArtifacts returns the artifacts with hrefs by href
+func (f *Fs) listArtifacts(ctx context.Context, hrefs []string) (artifacts map[string]*api.Artifact, err error) {
+	artifacts = map[string]*api.Artifact{}
+	if len(hrefs) == 0 {
+		return artifacts, nil
+	}
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   apiPath + "/artifacts/",
+		Parameters: url.Values{
+			"pulp_href__in": {strings.Join(hrefs, ",")},
+		},
+	}
+	err = f.paginate(ctx, &opts, func(results json.RawMessage) error {
+		var page []api.Artifact
+		err := json.Unmarshal(results, &page)
+		if err != nil {
+			return err
+		}
+		for i := range page {
+			artifacts[page[i].PulpHref] = &page[i]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list artifacts")
+	}
+	return artifacts, nil
+}
+
+// getArtifact reads the artifact by href
+func (f *Fs) getArtifact(ctx context.Context, href string) (file *api.Artifact, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   href,
+	}
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &file)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read artifact")
+	}
+	return file, nil
+}
+
+// findContent finds the file content unit with relativePath in the
+// latest version of repo returning it with its file
+//
+// It returns fs.ErrorObjectNotFound if there isn't one.
+func (f *Fs) findContent(ctx context.Context, repo *api.Repository, relativePath string) (content *api.FileContent, file *api.Artifact, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   apiPath + "/content/file/files/",
+		Parameters: url.Values{
+			"repository_version": {repo.LatestVersionHref},
+			"relative_path":      {relativePath},
+		},
+	}
+	err = f.paginate(ctx, &opts, func(results json.RawMessage) error {
+		var contents []api.FileContent
+		err := json.Unmarshal(results, &contents)
+		if err != nil {
+			return err
+		}
+		for i := range contents {
+			if contents[i].RelativePath == relativePath {
+				content = &contents[i]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to read content")
+	}
+	if content == nil {
+		return nil, nil, fs.ErrorObjectNotFound
+	}
+	file, err = f.getArtifact(ctx, content.Artifact)
+	if err != nil {
+		return nil, nil, err
+	}
+	return content, file, nil
+}
+
+// listContent calls fn for each file content unit in the latest
+// version of repo whose relative path starts with prefix, with its
+// file
+//
+// The content units are filtered by the server and only the
+// artifacts of each page of them are read.
+func (f *Fs) listContent(ctx context.Context, repo *api.Repository, prefix string, fn func(content *api.FileContent, file *api.Artifact) error) error {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   apiPath + "/content/file/files/",
+		Parameters: url.Values{
+			"repository_version": {repo.LatestVersionHref},
+		},
+	}
+	if prefix != "" {
+		opts.Parameters.Set("relative_path__startswith", prefix)
+	}
+	err := f.paginate(ctx, &opts, func(results json.RawMessage) error {
+		var contents []api.FileContent
+		err := json.Unmarshal(results, &contents)
+		if err != nil {
+			return err
+		}
+		hrefs := make([]string, len(contents))
+		for i := range contents {
+			hrefs[i] = contents[i].Artifact
+		}
+		artifacts, err := f.listArtifacts(ctx, hrefs)
+		if err != nil {
+			return err
+		}
+		for i := range contents {
+			file := artifacts[contents[i].Artifact]
+			if file == nil {
+				fs.Debugf(f, "Skipping %q as its artifact wasn't found", contents[i].RelativePath)
+				continue
+			}
+			err = fn(&contents[i], file)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list content")
+	}
+	return nil
+}
+
+// newObject makes an Object at remote for content
+func (f *Fs) newObject(remote string, content *api.FileContent, file *api.Artifact) *Object {
+	o := &Object{
+		fs:     f,
+		remote: remote,
+	}
+	o.setMetaData(content, file)
+	return o
+}
+
+// NewObject finds the Object at remote.  If it can't be found
+// it returns the error fs.ErrorObjectNotFound.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	repoName, repoPath := f.split(remote)
+	if repoName == "" || repoPath == "" {
+		return nil, fs.ErrorObjectNotFound
+	}
+	repo, err := f.getRepository(ctx, repoName)
+	if err == fs.ErrorDirNotFound {
+		return nil, fs.ErrorObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	content, file, err := f.findContent(ctx, repo, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	return f.newObject(remote, content, file), nil
+}
+
+// list the entries of directory in repoName calling fn for each one
+// with its remote relative to directory
+//
+// If recurse is set all the entries below directory are listed.
+func (f *Fs) list(ctx context.Context, repoName, directory string, recurse bool, fn func(entry fs.DirEntry) error) error {
+	repo, err := f.getRepository(ctx, repoName)
+	if err != nil {
+		return err
+	}
+	prefix := directory
+	if prefix != "" {
+		prefix += "/"
+	}
+	found := directory == ""
+	seenDirs := map[string]struct{}{}
+	addDir := func(remote string, modTime time.Time) error {
+		if _, ok := seenDirs[remote]; ok {
+			return nil
+		}
+		seenDirs[remote] = struct{}{}
+		return fn(fs.NewDir(remote, modTime))
+	}
+	err = f.listContent(ctx, repo, prefix, func(content *api.FileContent, file *api.Artifact) error {
+		if !strings.HasPrefix(content.RelativePath, prefix) {
+			return nil
+		}
+		found = true
+		remote := f.opt.Enc.ToStandardPath(content.RelativePath[len(prefix):])
+		dir, _ := path.Split(remote)
+		if dir != "" {
+			if !recurse {
+				// only show the first directory below directory
+				return addDir(strings.SplitN(dir, "/", 2)[0], content.PulpCreated)
+			}
+			// add all the parent directories
+			dir = strings.TrimSuffix(dir, "/")
+			for dir != "." && dir != "" {
+				err := addDir(dir, content.PulpCreated)
+				if err != nil {
+					return err
+				}
+				dir = path.Dir(dir)
+			}
+		}
+		return fn(f.newObject(remote, content, file))
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fs.ErrorDirNotFound
+	}
+	return nil
+}
+
+// List the objects and directories in dir into entries.  The
+// entries can be returned in any order but should be for a
+// complete directory.
+//
+// dir should be "" to list the root, and should not have
+// trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	repo, directory := f.split(dir)
+	if repo == "" {
+		if directory != "" {
+			return nil, fs.ErrorListBucketRequired
+		}
+		return f.listRepositories(ctx)
+	}
+	err = f.list(ctx, repo, directory, false, func(entry fs.DirEntry) error {
+		entries = append(entries, prefixEntry(dir, entry))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// prefixEntry makes the remote of entry relative to the root by
+// adding dir to it
+func prefixEntry(dir string, entry fs.DirEntry) fs.DirEntry {
+	switch x := entry.(type) {
+	case *Object:
+		x.remote = path.Join(dir, x.remote)
+	case *fs.Dir:
+		entry = fs.NewDir(path.Join(dir, x.Remote()), x.ModTime(context.Background()))
+	}
+	return entry
+}
+
+// ListR lists the objects and directories of the Fs starting
+// from dir recursively into out.
+//
+// dir should be "" to start from the root, and should not
+// have trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+//
+// It should call callback for each tranche of entries read.
+// These need not be returned in any particular order.  If
+// callback returns an error then the listing will stop
+// immediately.
+//
+// Don't implement this unless you have a more efficient way
+// of listing recursively than doing a directory traversal.
+func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (err error) {
+	repo, directory := f.split(dir)
+	list := walk.NewListRHelper(callback)
+	listR := func(repo, directory, prefix string) error {
+		return f.list(ctx, repo, directory, true, func(entry fs.DirEntry) error {
+			return list.Add(prefixEntry(prefix, entry))
+		})
+	}
+	if repo == "" {
+		if directory != "" {
+			return fs.ErrorListBucketRequired
+		}
+		entries, err := f.listRepositories(ctx)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			err = list.Add(entry)
+			if err != nil {
+				return err
+			}
+			err = listR(f.opt.Enc.FromStandardName(entry.Remote()), "", entry.Remote())
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err = listR(repo, directory, dir)
+		if err != nil {
+			return err
+		}
+	}
+	return list.Flush()
+}
+
+// Put the object into the repository
+//
+// Copy the reader in to the new object which is returned
+//
+// The new object may have been created if an error is returned
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	o := &Object{
+		fs:     f,
+		remote: src.Remote(),
+	}
+	return o, o.Update(ctx, in, src, options...)
+}
+
+// Mkdir creates the directory if it doesn't exist
+//
+// Repositories can't be created so this returns an error if the
+// repository doesn't exist. Other directories are made from the paths
+// of the files so there is nothing to do for them.
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	repo, _ := f.split(dir)
+	if repo == "" {
+		return nil
+	}
+	_, err := f.getRepository(ctx, repo)
+	if err == fs.ErrorDirNotFound {
+		return errors.Errorf("repository %q not found - create it in Pulp first", repo)
+	}
+	return err
+}
+
+// Rmdir deletes the directory if it is empty
+//
+// Returns an error if it isn't empty
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	repo, repoPath := f.split(dir)
+	if repo == "" {
+		return nil
+	}
+	if repoPath == "" {
+		return errors.Errorf("can't remove repository %q", repo)
+	}
+	entries, err := f.List(ctx, dir)
+	if err == fs.ErrorDirNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) != 0 {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	return nil
+}
+
+// waitTask waits for the task at href to finish
+func (f *Fs) waitTask(ctx context.Context, href string) (task *api.Task, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   href,
+	}
+	for {
+		var resp *http.Response
+		err = f.pacer.Call(func() (bool, error) {
+			resp, err = f.srv.CallJSON(ctx, &opts, nil, &task)
+			return shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read task")
+		}
+		switch task.State {
+		case api.TaskCompleted:
+			return task, nil
+		case api.TaskFailed, api.TaskCanceled:
+			if task.Error != nil && task.Error.Description != "" {
+				return task, errors.Errorf("task %s: %s", task.State, task.Error.Description)
+			}
+			return task, errors.Errorf("task %s", task.State)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(taskPollInterval):
+		}
+	}
+}
+
+// callTask makes the call in opts with request and waits for the
+// task it starts to finish
+func (f *Fs) callTask(ctx context.Context, opts *rest.Opts, request interface{}) (*api.Task, error) {
+	var result api.AsyncOperation
+	var resp *http.Response
+	var err error
+	err = f.pacer.CallNoRetry(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, opts, request, &result)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f.waitTask(ctx, result.Task)
+}
+
+// modify adds and removes content units from repo making a new
+// repository version
+func (f *Fs) modify(ctx context.Context, repo *api.Repository, request *api.ModifyRepository) error {
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   repo.PulpHref + "modify/",
+	}
+	_, err := f.callTask(ctx, &opts, request)
+	if err != nil {
+		return errors.Wrap(err, "failed to modify repository")
+	}
+	return nil
+}
+
+// Purge deletes all the files in the directory
+//
+// The files are removed from the repository in one new version.
+func (f *Fs) Purge(ctx context.Context, dir string) error {
+	repoName, directory := f.split(dir)
+	if directory == "" {
+		// Don't empty whole repositories in one go
+		return fs.ErrorCantPurge
+	}
+	var hrefs []string
+	err := f.list(ctx, repoName, directory, true, func(entry fs.DirEntry) error {
+		if o, ok := entry.(*Object); ok {
+			hrefs = append(hrefs, o.contentHref)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	repo, err := f.getRepository(ctx, repoName)
+	if err != nil {
+		return err
+	}
+	return f.modify(ctx, repo, &api.ModifyRepository{
+		RemoveContentUnits: hrefs,
+	})
+}
+
+// distributionURL returns the URL of the content app serving repo
+func (f *Fs) distributionURL(ctx context.Context, repo *api.Repository) (string, error) {
+	f.distMu.Lock()
+	defer f.distMu.Unlock()
+	if u, ok := f.distURLs[repo.PulpHref]; ok {
+		return u, nil
+	}
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   apiPath + "/distributions/file/file/",
+	}
+	err := f.paginate(ctx, &opts, func(results json.RawMessage) error {
+		var dists []api.Distribution
+		err := json.Unmarshal(results, &dists)
+		if err != nil {
+			return err
+		}
+		for _, dist := range dists {
+			if dist.Repository == "" || dist.BaseURL == "" {
+				continue
+			}
+			u := dist.BaseURL
+			// older servers return the path only
+			if strings.HasPrefix(u, "/") {
+				u = strings.TrimRight(f.opt.Endpoint, "/") + u
+			}
+			if !strings.HasSuffix(u, "/") {
+				u += "/"
+			}
+			f.distURLs[dist.Repository] = u
+		}
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list distributions")
+	}
+	u, ok := f.distURLs[repo.PulpHref]
+	if !ok {
+		return "", errors.Errorf("no distribution serves repository %q - create one to download files", repo.Name)
+	}
+	return u, nil
+}
+
+// Precision of the ModTimes in this Fs
+func (f *Fs) Precision() time.Duration {
+	return fs.ModTimeNotSupported
+}
+
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() hash.Set {
+	return artifact.ChecksumTypes()
+}
+
+// ------------------------------------------------------------
+
+// Fs returns the parent Fs
+func (o *Object) Fs() fs.Info {
+	return o.fs
+}
+
+// Return a string version
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// Remote returns the remote path
+func (o *Object) Remote() string {
+	return o.remote
+}
+
+// Hash returns the MD5 or SHA-1 of an object returning a lowercase hex string
+func (o *Object) Hash(ctx context.Context, t hash.Type) (string, error) {
+	return o.checksums.Get(t)
+}
+
+// Size returns the size of an object in bytes
+func (o *Object) Size() int64 {
+	return o.size
+}
+
+// setMetaData sets the metadata from content and artifact
+func (o *Object) setMetaData(content *api.FileContent, file *api.Artifact) {
+	o.contentHref = content.PulpHref
+	o.artifactHref = file.PulpHref
+	o.size = file.Size
+	o.modTime = content.PulpCreated
+	o.checksums = artifact.NewChecksums(file.MD5, file.SHA1)
+}
+
+// ModTime returns the modification time of the object
+//
+// This is the time the content unit was created
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	return o.modTime
+}
+
+// SetModTime sets the modification time of the object
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	return fs.ErrorCantSetModTime
+}
+
+// Storable returns a boolean showing whether this object storable
+func (o *Object) Storable() bool {
+	return true
+}
+
+// ID returns the href of the content unit
+func (o *Object) ID() string {
+	return o.contentHref
+}
+
+// Open an object for read
+//
+// Files are read from the content app through a distribution of the
+// repository.
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
+	repoName, repoPath := o.split()
+	repo, err := o.fs.getRepository(ctx, repoName)
+	if err != nil {
+		return nil, err
+	}
+	baseURL, err := o.fs.distributionURL(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	fs.FixRangeOption(options, o.size)
+	opts := rest.Opts{
+		Method:  "GET",
+		RootURL: baseURL + rest.URLPathEscape(repoPath),
+		Options: options,
+	}
+	var resp *http.Response
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, err
+}
+
+// uploadChunks uploads in to a new upload of size bytes in chunks,
+// returning the href of the upload and the SHA-256 of the data
+func (o *Object) uploadChunks(ctx context.Context, in io.Reader, size int64, options ...fs.OpenOption) (uploadHref, sha256sum string, err error) {
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   apiPath + "/uploads/",
+	}
+	var upload api.Upload
+	var resp *http.Response
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.CallJSON(ctx, &opts, &api.CreateUpload{Size: size}, &upload)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to start upload")
+	}
+	hasher := sha256.New()
+	buf := make([]byte, o.fs.opt.ChunkSize)
+	for offset := int64(0); offset < size; {
+		chunkSize := int64(len(buf))
+		if size-offset < chunkSize {
+			chunkSize = size - offset
+		}
+		chunk := buf[:chunkSize]
+		_, err = io.ReadFull(in, chunk)
+		if err != nil {
+			return "", "", errors.Wrap(err, "failed to read source")
+		}
+		_, _ = hasher.Write(chunk)
+		opts := rest.Opts{
+			Method:               "PUT",
+			Path:                 upload.PulpHref,
+			ContentRange:         fmt.Sprintf("bytes %d-%d/*", offset, offset+chunkSize-1),
+			MultipartParams:      url.Values{},
+			MultipartContentName: "file",
+			MultipartFileName:    "chunk",
+			Options:              options,
+			NoResponse:           true,
+		}
+		err = o.fs.pacer.Call(func() (bool, error) {
+			opts.Body = bytes.NewReader(chunk)
+			length := chunkSize
+			opts.ContentLength = &length
+			resp, err = o.fs.srv.CallJSON(ctx, &opts, nil, nil)
+			return shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return "", "", errors.Wrap(err, "failed to upload chunk")
+		}
+		offset += chunkSize
+	}
+	return upload.PulpHref, hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// commitUpload turns the upload into an artifact returning its href
+//
+// If the server already has an artifact with the same content it is
+// used instead.
+func (o *Object) commitUpload(ctx context.Context, uploadHref, sha256sum string) (string, error) {
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   uploadHref + "commit/",
+	}
+	task, err := o.fs.callTask(ctx, &opts, &api.CommitUpload{SHA256: sha256sum})
+	if err == nil {
+		for _, href := range task.CreatedResources {
+			if strings.Contains(href, "/artifacts/") {
+				return href, nil
+			}
+		}
+	}
+	// Look for an existing artifact
+	opts = rest.Opts{
+		Method: "GET",
+		Path:   apiPath + "/artifacts/",
+		Parameters: url.Values{
+			"sha256": {sha256sum},
+		},
+	}
+	var artifactHref string
+	findErr := o.fs.paginate(ctx, &opts, func(results json.RawMessage) error {
+		var artifacts []api.Artifact
+		err := json.Unmarshal(results, &artifacts)
+		if err == nil && len(artifacts) > 0 {
+			artifactHref = artifacts[0].PulpHref
+		}
+		return err
+	})
+	if findErr == nil && artifactHref != "" {
+		return artifactHref, nil
+	}
+	if err == nil {
+		err = errors.New("no artifact created")
+	}
+	return "", errors.Wrap(err, "failed to commit upload")
+}
+
+// createContent returns the href of the file content unit with
+// relativePath and artifactHref, creating it if it doesn't exist
+func (o *Object) createContent(ctx context.Context, relativePath, artifactHref, sha256sum string) (string, error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   apiPath + "/content/file/files/",
+		Parameters: url.Values{
+			"relative_path": {relativePath},
+			"sha256":        {sha256sum},
+		},
+	}
+	var contentHref string
+	err := o.fs.paginate(ctx, &opts, func(results json.RawMessage) error {
+		var contents []api.FileContent
+		err := json.Unmarshal(results, &contents)
+		if err == nil && len(contents) > 0 {
+			contentHref = contents[0].PulpHref
+		}
+		return err
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to look for content")
+	}
+	if contentHref != "" {
+		return contentHref, nil
+	}
+	opts = rest.Opts{
+		Method: "POST",
+		Path:   apiPath + "/content/file/files/",
+	}
+	task, err := o.fs.callTask(ctx, &opts, &api.CreateFileContent{
+		RelativePath: relativePath,
+		Artifact:     artifactHref,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create content")
+	}
+	for _, href := range task.CreatedResources {
+		if strings.Contains(href, "/content/") {
+			return href, nil
+		}
+	}
+	return "", errors.New("failed to create content: no content created")
+}
+
+// Update the object with the contents of the io.Reader, modTime and size
+//
+// The data is uploaded in chunks and made into a content unit which
+// is added to a new version of the repository, replacing any content
+// unit with the same path.
+//
+// The new object may have been created if an error is returned
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (err error) {
+	repoName, repoPath := o.split()
+	if repoName == "" || repoPath == "" {
+		return errors.New("can't upload files to the root")
+	}
+	size := src.Size()
+	if size < 0 {
+		return errors.New("can't upload files of unknown size")
+	}
+	repo, err := o.fs.getRepository(ctx, repoName)
+	if err == fs.ErrorDirNotFound {
+		return errors.Errorf("repository %q not found - create it in Pulp first", repoName)
+	}
+	if err != nil {
+		return err
+	}
+	uploadHref, sha256sum, err := o.uploadChunks(ctx, in, size, options...)
+	if err != nil {
+		return err
+	}
+	artifactHref, err := o.commitUpload(ctx, uploadHref, sha256sum)
+	if err != nil {
+		return err
+	}
+	contentHref, err := o.createContent(ctx, repoPath, artifactHref, sha256sum)
+	if err != nil {
+		return err
+	}
+	err = o.fs.modify(ctx, repo, &api.ModifyRepository{
+		AddContentUnits: []string{contentHref},
+	})
+	if err != nil {
+		return err
+	}
+	newObj, err := o.fs.NewObject(ctx, o.remote)
+	if err != nil {
+		return errors.Wrap(err, "failed to read uploaded file")
+	}
+	*o = *newObj.(*Object)
+	return nil
+}
+
+// Remove an object
+//
+// This removes the content unit from the repository in a new version.
+func (o *Object) Remove(ctx context.Context) error {
+	repoName, _ := o.split()
+	repo, err := o.fs.getRepository(ctx, repoName)
+	if err != nil {
+		return err
+	}
+	return o.fs.modify(ctx, repo, &api.ModifyRepository{
+		RemoveContentUnits: []string{o.contentHref},
+	})
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs      = &Fs{}
+	_ fs.Purger  = &Fs{}
+	_ fs.ListRer = &Fs{}
+	_ fs.Object  = &Object{}
+	_ fs.IDer    = &Object{}
+)
@@ -0,0 +1,187 @@
+package pulp
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRepoHref    = apiPath + "/repositories/file/file/1/"
+	testVersionHref = testRepoHref + "versions/2/"
+)
+
+// testContents are the file content units of the test repository
+var testContents = []struct {
+	path string
+	json string
+}{
+	{"hello.txt", `{"pulp_href":"/c/1/","pulp_created":"2021-01-02T03:04:05.000000Z","relative_path":"hello.txt","artifact":"/a/1/"}`},
+	{"dir/sub/one.txt", `{"pulp_href":"/c/2/","pulp_created":"2021-01-02T03:04:05.000000Z","relative_path":"dir/sub/one.txt","artifact":"/a/2/"}`},
+	{"dir/two.txt", `{"pulp_href":"/c/3/","pulp_created":"2021-01-02T03:04:05.000000Z","relative_path":"dir/two.txt","artifact":"/a/3/"}`},
+}
+
+// testArtifacts are the artifacts of testContents by href
+var testArtifacts = map[string]string{
+	"/a/1/": `{"pulp_href":"/a/1/","size":5,"md5":"5d41402abc4b2a76b9719d911017c592","sha1":"aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"}`,
+	"/a/2/": `{"pulp_href":"/a/2/","size":3}`,
+	"/a/3/": `{"pulp_href":"/a/3/","size":1}`,
+}
+
+// writePage writes a page of results with the next link if set
+func writePage(w http.ResponseWriter, results []string, next string) {
+	nextJSON := "null"
+	if next != "" {
+		nextJSON = strconv.Quote(next)
+	}
+	_, _ = fmt.Fprintf(w, `{"count":%d,"next":%s,"results":[%s]}`, len(results), nextJSON, strings.Join(results, ","))
+}
+
+// contentQueries records the relative_path__startswith filters used
+// to list content
+var contentQueries sync.Map
+
+// prepare starts a test server and returns an Fs using it and the
+// number of times the artifacts were listed
+func prepare(t *testing.T) (*Fs, *int32, func()) {
+	var ts *httptest.Server
+	var artifactLists int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case apiPath + "/repositories/file/file/":
+			if name := query.Get("name"); name != "" && name != "files" {
+				_, _ = fmt.Fprint(w, `{"count":0,"next":null,"results":[]}`)
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"count":1,"next":null,"results":[{"pulp_href":%q,"name":"files","latest_version_href":%q}]}`, testRepoHref, testVersionHref)
+		case apiPath + "/artifacts/":
+			atomic.AddInt32(&artifactLists, 1)
+			var results []string
+			for _, href := range strings.Split(query.Get("pulp_href__in"), ",") {
+				if result, ok := testArtifacts[href]; ok {
+					results = append(results, result)
+				}
+			}
+			writePage(w, results, "")
+		case apiPath + "/content/file/files/":
+			assert.Equal(t, testVersionHref, query.Get("repository_version"))
+			relativePath, prefix := query.Get("relative_path"), query.Get("relative_path__startswith")
+			contentQueries.Store(prefix, true)
+			var results []string
+			for _, content := range testContents {
+				if (relativePath == "" || relativePath == content.path) && strings.HasPrefix(content.path, prefix) {
+					results = append(results, content.json)
+				}
+			}
+			// return the content in pages of two to check pagination
+			if query.Get("offset") == "" && len(results) > 2 {
+				next := fmt.Sprintf("%s%s/content/file/files/?offset=2&%s", ts.URL, apiPath, query.Encode())
+				writePage(w, results[:2], next)
+				return
+			}
+			if query.Get("offset") != "" {
+				results = results[2:]
+			}
+			writePage(w, results, "")
+		case "/a/1/":
+			_, _ = fmt.Fprint(w, testArtifacts["/a/1/"])
+		case apiPath + "/distributions/file/file/":
+			_, _ = fmt.Fprintf(w, `{"count":1,"next":null,"results":[{"name":"files","base_path":"files","base_url":"/pulp/content/files","repository":%q}]}`, testRepoHref)
+		case "/pulp/content/files/hello.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = fmt.Fprint(w, "hello")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"detail":"Not found."}`)
+		}
+	})
+	ts = httptest.NewServer(handler)
+	f, err := NewFs(context.Background(), "TestPulp", "", configmap.Simple{
+		"endpoint": ts.URL,
+	})
+	require.NoError(t, err)
+	return f.(*Fs), &artifactLists, ts.Close
+}
+
+// listNames lists dir returning the sorted remotes
+func listNames(t *testing.T, f fs.Fs, dir string) []string {
+	entries, err := f.List(context.Background(), dir)
+	require.NoError(t, err, dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Remote())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestList(t *testing.T) {
+	f, _, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	assert.Equal(t, []string{"files"}, listNames(t, f, ""))
+	assert.Equal(t, []string{"files/dir", "files/hello.txt"}, listNames(t, f, "files"))
+	assert.Equal(t, []string{"files/dir/sub", "files/dir/two.txt"}, listNames(t, f, "files/dir"))
+	_, ok := contentQueries.Load("dir/")
+	assert.True(t, ok, "listing a directory should filter the content by its path")
+
+	_, err := f.List(ctx, "files/potato")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+	_, err = f.List(ctx, "potato")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+
+	var names []string
+	err = f.ListR(ctx, "files/dir", func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			names = append(names, entry.Remote())
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	sort.Strings(names)
+	assert.Equal(t, []string{"files/dir/sub", "files/dir/sub/one.txt", "files/dir/two.txt"}, names)
+}
+
+func TestObject(t *testing.T) {
+	f, artifactLists, tidy := prepare(t)
+	defer tidy()
+	ctx := context.Background()
+
+	o, err := f.NewObject(ctx, "files/hello.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), o.Size())
+	assert.Equal(t, 2021, o.ModTime(ctx).Year())
+	md5, err := o.Hash(ctx, hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", md5)
+	assert.Equal(t, int32(0), atomic.LoadInt32(artifactLists), "finding an object shouldn't list the artifacts")
+
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "hello", string(data))
+
+	_, err = f.NewObject(ctx, "files/potato.txt")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+	_, err = f.NewObject(ctx, "potato/hello.txt")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+}
@@ -0,0 +1,17 @@
+// Test Pulp filesystem interface
+package pulp_test
+
+import (
+	"testing"
+
+	"github.com/rclone/rclone/backend/pulp"
+	"github.com/rclone/rclone/fstest/fstests"
+)
+
+// TestIntegration runs integration tests against the remote
+func TestIntegration(t *testing.T) {
+	fstests.Run(t, &fstests.Opt{
+		RemoteName: "TestPulp:rclone-test",
+		NilObject:  (*pulp.Object)(nil),
+	})
+}
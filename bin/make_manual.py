@@ -29,6 +29,9 @@ docs = [
     "alias.md",
     "amazonclouddrive.md",
     "s3.md",
+    "archiva.md",
+    "artifactory.md",
+    "codeartifact.md",
     "b2.md",
     "box.md",
     "cache.md",
@@ -39,6 +42,8 @@ docs = [
     "dropbox.md",
     "filefabric.md",
     "ftp.md",
+    "githubpackages.md",
+    "gitlab.md",
     "googlecloudstorage.md",
     "drive.md",
     "googlephotos.md",
@@ -48,6 +53,7 @@ docs = [
     "jottacloud.md",
     "koofr.md",
     "mailru.md",
+    "maven.md",
     "mega.md",
     "memory.md",
     "azureblob.md",
@@ -57,6 +63,8 @@ docs = [
     "swift.md",
     "pcloud.md",
     "premiumizeme.md",
+    "proget.md",
+    "pulp.md",
     "putio.md",
     "seafile.md",
     "sftp.md",
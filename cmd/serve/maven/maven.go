@@ -0,0 +1,512 @@
+// Package maven implements a maven2 repository server for rclone
+package maven
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"html/template"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/serve/http/data"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/list"
+	"github.com/rclone/rclone/lib/artifact"
+	httplib "github.com/rclone/rclone/lib/http"
+	"github.com/rclone/rclone/lib/http/auth"
+	"github.com/rclone/rclone/lib/http/serve"
+	"github.com/spf13/cobra"
+)
+
+// Options required for maven server
+type Options struct {
+	data.Options
+}
+
+// DefaultOpt is the default values used for Options
+var DefaultOpt = Options{}
+
+// Opt is options set by command line flags
+var Opt = DefaultOpt
+
+func init() {
+	data.AddFlags(Command.Flags(), "", &Opt.Options)
+	httplib.AddFlags(Command.Flags())
+	auth.AddFlags(Command.Flags())
+}
+
+// Command definition for cobra
+var Command = &cobra.Command{
+	Use:   "maven remote:path",
+	Short: `Serve the remote as a maven2 repository.`,
+	Long: `rclone serve maven implements a read only maven2 repository on top
+of any remote so it can be used directly by build tools such as maven
+or gradle.
+
+The remote is expected to hold the files in the maven2 layout, e.g.
+
+    org/example/app/1.0/app-1.0.jar
+
+Files which exist on the remote are served as they are. The files
+that repository managers usually generate are made on the fly when
+they are missing:
+
+- ` + "`maven-metadata.xml`" + ` in an artifact directory lists the version
+  directories found in it, with the latest and release versions
+  worked out from the version numbers.
+- ` + "`maven-metadata.xml`" + ` in a snapshot version directory lists the
+  timestamped files found in it.
+- ` + "`.md5`" + ` and ` + "`.sha1`" + ` checksum files are made from the hashes
+  the backend supplies, or by reading the file if it doesn't supply
+  them.
+
+Directories are served as HTML indexes.
+
+You can use the filter flags (e.g. --include, --exclude) to control what
+is served.
+
+The server will log errors.  Use -v to see access logs.
+
+--bwlimit will be respected for file transfers.  Use --stats to
+control the stats printing.
+` + httplib.Help + data.Help + auth.Help,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		f := cmd.NewFsSrc(args)
+		cmd.Run(false, true, command, func() error {
+			s := newServer(f, Opt.Template)
+			router, err := httplib.Router()
+			if err != nil {
+				return err
+			}
+			s.Bind(router)
+			return nil
+		})
+	},
+}
+
+// metadataName is the name of the maven metadata files
+const metadataName = "maven-metadata.xml"
+
+// snapshotSuffix is the suffix of snapshot versions
+const snapshotSuffix = "-SNAPSHOT"
+
+// timeFormat is the format of the timestamps in the metadata
+const timeFormat = "20060102150405"
+
+// checksumTypes are the hash types checksum files are made for
+var checksumTypes = []hash.Type{hash.MD5, hash.SHA1}
+
+// server contains everything to run the server
+type server struct {
+	f            fs.Fs
+	HTMLTemplate *template.Template // HTML template for web interface
+}
+
+func newServer(f fs.Fs, templatePath string) *server {
+	htmlTemplate, templateErr := data.GetTemplate(templatePath)
+	if templateErr != nil {
+		log.Fatalf(templateErr.Error())
+	}
+	return &server{
+		f:            f,
+		HTMLTemplate: htmlTemplate,
+	}
+}
+
+func (s *server) Bind(router chi.Router) {
+	router.Use(
+		middleware.SetHeader("Accept-Ranges", "bytes"),
+		middleware.SetHeader("Server", "rclone/"+fs.Version),
+	)
+	router.Get("/*", s.handler)
+	router.Head("/*", s.handler)
+}
+
+// handler reads incoming requests and dispatches them
+func (s *server) handler(w http.ResponseWriter, r *http.Request) {
+	isDir := strings.HasSuffix(r.URL.Path, "/")
+	remote := strings.Trim(r.URL.Path, "/")
+	if isDir {
+		s.serveDir(w, r, remote)
+	} else {
+		s.serveFile(w, r, remote)
+	}
+}
+
+// serveDir serves a directory index at dirRemote
+func (s *server) serveDir(w http.ResponseWriter, r *http.Request, dirRemote string) {
+	entries, err := list.DirSorted(r.Context(), s.f, false, dirRemote)
+	if err == fs.ErrorDirNotFound {
+		http.Error(w, "Directory not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serve.Error(dirRemote, w, "Failed to list directory", err)
+		return
+	}
+
+	directory := serve.NewDirectory(dirRemote, s.HTMLTemplate)
+	for _, entry := range entries {
+		_, isDir := entry.(fs.Directory)
+		directory.AddHTMLEntry(entry.Remote(), isDir, entry.Size(), entry.ModTime(r.Context()).UTC())
+	}
+	sortParm := r.URL.Query().Get("sort")
+	orderParm := r.URL.Query().Get("order")
+	directory.ProcessQueryParams(sortParm, orderParm)
+	directory.Serve(w, r)
+}
+
+// serveFile serves the file at remote, generating it if it is a
+// metadata or checksum file which is missing from the remote
+func (s *server) serveFile(w http.ResponseWriter, r *http.Request, remote string) {
+	o, err := s.f.NewObject(r.Context(), remote)
+	if err == nil {
+		serve.Object(w, r, o)
+		return
+	}
+	if err != fs.ErrorObjectNotFound && err != fs.ErrorIsDir {
+		serve.Error(remote, w, "Failed to find file", err)
+		return
+	}
+	content, modTime, err := s.generate(r.Context(), remote)
+	if err == fs.ErrorObjectNotFound {
+		fs.Infof(remote, "%s: File not found", r.RemoteAddr)
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serve.Error(remote, w, "Failed to generate file", err)
+		return
+	}
+	fs.Debugf(remote, "%s: Serving generated file", r.RemoteAddr)
+	if path.Base(remote) == metadataName {
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	http.ServeContent(w, r, remote, modTime, bytes.NewReader(content))
+}
+
+// generate makes the content of the file at remote if it is one the
+// server can make, returning fs.ErrorObjectNotFound otherwise
+func (s *server) generate(ctx context.Context, remote string) (content []byte, modTime time.Time, err error) {
+	for _, t := range checksumTypes {
+		if ext := artifact.ChecksumExtension(t); strings.HasSuffix(remote, ext) {
+			return s.checksum(ctx, strings.TrimSuffix(remote, ext), t)
+		}
+	}
+	if path.Base(remote) == metadataName {
+		return s.metadata(ctx, path.Dir(remote))
+	}
+	return nil, modTime, fs.ErrorObjectNotFound
+}
+
+// checksum makes the checksum file of type t for the file at remote
+// which may itself be a generated file
+func (s *server) checksum(ctx context.Context, remote string, t hash.Type) (content []byte, modTime time.Time, err error) {
+	var sum string
+	o, err := s.f.NewObject(ctx, remote)
+	switch err {
+	case nil:
+		modTime = o.ModTime(ctx)
+		sum, err = objectHash(ctx, o, t)
+		if err != nil {
+			return nil, modTime, err
+		}
+	case fs.ErrorObjectNotFound, fs.ErrorIsDir:
+		if path.Base(remote) != metadataName {
+			return nil, modTime, fs.ErrorObjectNotFound
+		}
+		content, modTime, err = s.metadata(ctx, path.Dir(remote))
+		if err != nil {
+			return nil, modTime, err
+		}
+		sums, err := hash.StreamTypes(bytes.NewReader(content), hash.NewHashSet(t))
+		if err != nil {
+			return nil, modTime, err
+		}
+		sum = sums[t]
+	default:
+		return nil, modTime, err
+	}
+	return []byte(sum), modTime, nil
+}
+
+// objectHash returns the hash of type t of o, reading the object if
+// the backend doesn't supply it
+func objectHash(ctx context.Context, o fs.Object, t hash.Type) (sum string, err error) {
+	sum, err = o.Hash(ctx, t)
+	if err != nil && err != hash.ErrUnsupported {
+		return "", err
+	}
+	if sum != "" {
+		return sum, nil
+	}
+	fs.Debugf(o, "Reading file to calculate %v", t)
+	in, err := o.Open(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open file to calculate checksum")
+	}
+	defer fs.CheckClose(in, &err)
+	sums, err := hash.StreamTypes(in, hash.NewHashSet(t))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to calculate checksum")
+	}
+	return sums[t], nil
+}
+
+// Metadata is the content of a maven-metadata.xml file
+type Metadata struct {
+	XMLName    xml.Name   `xml:"metadata"`
+	GroupID    string     `xml:"groupId"`
+	ArtifactID string     `xml:"artifactId"`
+	Version    string     `xml:"version,omitempty"`
+	Versioning Versioning `xml:"versioning"`
+}
+
+// Versioning is the versioning section of the metadata
+type Versioning struct {
+	Latest           string            `xml:"latest,omitempty"`
+	Release          string            `xml:"release,omitempty"`
+	Snapshot         *Snapshot         `xml:"snapshot,omitempty"`
+	Versions         []string          `xml:"versions>version,omitempty"`
+	LastUpdated      string            `xml:"lastUpdated"`
+	SnapshotVersions []SnapshotVersion `xml:"snapshotVersions>snapshotVersion,omitempty"`
+}
+
+// Snapshot is the latest build of a snapshot version
+type Snapshot struct {
+	Timestamp   string `xml:"timestamp"`
+	BuildNumber int    `xml:"buildNumber"`
+}
+
+// SnapshotVersion is a file of a snapshot version
+type SnapshotVersion struct {
+	Classifier string `xml:"classifier,omitempty"`
+	Extension  string `xml:"extension"`
+	Value      string `xml:"value"`
+	Updated    string `xml:"updated"`
+}
+
+// metadata makes the maven-metadata.xml for dir
+//
+// If dir is a snapshot version directory this describes the files
+// in it, otherwise dir is taken to be an artifact directory and this
+// describes the version directories in it.
+func (s *server) metadata(ctx context.Context, dir string) (content []byte, modTime time.Time, err error) {
+	if dir == "." || dir == "" || path.Dir(dir) == "." {
+		return nil, modTime, fs.ErrorObjectNotFound
+	}
+	entries, err := list.DirSorted(ctx, s.f, false, dir)
+	if err == fs.ErrorDirNotFound {
+		return nil, modTime, fs.ErrorObjectNotFound
+	} else if err != nil {
+		return nil, modTime, err
+	}
+	var metadata *Metadata
+	if strings.HasSuffix(dir, snapshotSuffix) {
+		metadata, modTime = snapshotMetadata(ctx, dir, entries)
+	} else {
+		metadata, modTime = artifactMetadata(ctx, dir, entries)
+	}
+	if metadata == nil {
+		return nil, modTime, fs.ErrorObjectNotFound
+	}
+	metadata.Versioning.LastUpdated = modTime.UTC().Format(timeFormat)
+	content, err = xml.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return nil, modTime, errors.Wrap(err, "failed to make metadata")
+	}
+	return append([]byte(xml.Header), append(content, '\n')...), modTime, nil
+}
+
+// groupID returns the groupId of the artifact in dir
+func groupID(dir string) string {
+	return strings.Replace(path.Dir(dir), "/", ".", -1)
+}
+
+// isVersion returns true if the directory name looks like a version,
+// which distinguishes artifact directories from group directories
+func isVersion(name string) bool {
+	return name != "" && name[0] >= '0' && name[0] <= '9'
+}
+
+// artifactMetadata makes the metadata of the artifact in dir from
+// the version directories in entries, returning nil if there aren't
+// any
+func artifactMetadata(ctx context.Context, dir string, entries fs.DirEntries) (*Metadata, time.Time) {
+	var modTime time.Time
+	var versions []string
+	for _, entry := range entries {
+		if _, ok := entry.(fs.Directory); !ok {
+			continue
+		}
+		version := path.Base(entry.Remote())
+		if !isVersion(version) {
+			continue
+		}
+		versions = append(versions, version)
+		if t := entry.ModTime(ctx); t.After(modTime) {
+			modTime = t
+		}
+	}
+	if len(versions) == 0 {
+		return nil, modTime
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
+	})
+	metadata := &Metadata{
+		GroupID:    groupID(dir),
+		ArtifactID: path.Base(dir),
+	}
+	metadata.Versioning.Versions = versions
+	metadata.Versioning.Latest = versions[len(versions)-1]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !strings.HasSuffix(versions[i], snapshotSuffix) {
+			metadata.Versioning.Release = versions[i]
+			break
+		}
+	}
+	return metadata, modTime
+}
+
+// snapshotMetadata makes the metadata of the snapshot version in dir
+// from the files in entries, returning nil if there aren't any
+func snapshotMetadata(ctx context.Context, dir string, entries fs.DirEntries) (*Metadata, time.Time) {
+	var modTime time.Time
+	version := path.Base(dir)
+	artifactDir := path.Dir(dir)
+	artifactID := path.Base(artifactDir)
+	metadata := &Metadata{
+		GroupID:    groupID(artifactDir),
+		ArtifactID: artifactID,
+		Version:    version,
+	}
+	// timestampRe matches the unique version of a file name
+	timestampRe := regexp.MustCompile(`^` + regexp.QuoteMeta(strings.TrimSuffix(version, snapshotSuffix)) + `-(\d{8}\.\d{6})-(\d+)`)
+	prefix := artifactID + "-"
+	for _, entry := range entries {
+		o, ok := entry.(fs.Object)
+		if !ok {
+			continue
+		}
+		name := path.Base(o.Remote())
+		if !strings.HasPrefix(name, prefix) || isChecksum(name) {
+			continue
+		}
+		rest := name[len(prefix):]
+		var value string
+		if strings.HasPrefix(rest, version) {
+			value = version
+		} else if match := timestampRe.FindStringSubmatch(rest); match != nil {
+			value = match[0]
+			buildNumber, _ := strconv.Atoi(match[2])
+			if metadata.Versioning.Snapshot == nil || buildNumber > metadata.Versioning.Snapshot.BuildNumber {
+				metadata.Versioning.Snapshot = &Snapshot{
+					Timestamp:   match[1],
+					BuildNumber: buildNumber,
+				}
+			}
+		} else {
+			continue
+		}
+		rest = rest[len(value):]
+		var classifier string
+		if strings.HasPrefix(rest, "-") {
+			i := strings.IndexRune(rest, '.')
+			if i < 0 {
+				continue
+			}
+			classifier, rest = rest[1:i], rest[i:]
+		}
+		if !strings.HasPrefix(rest, ".") || len(rest) == 1 {
+			continue
+		}
+		updated := o.ModTime(ctx)
+		if updated.After(modTime) {
+			modTime = updated
+		}
+		metadata.Versioning.SnapshotVersions = append(metadata.Versioning.SnapshotVersions, SnapshotVersion{
+			Classifier: classifier,
+			Extension:  rest[1:],
+			Value:      value,
+			Updated:    updated.UTC().Format(timeFormat),
+		})
+	}
+	if len(metadata.Versioning.SnapshotVersions) == 0 {
+		return nil, modTime
+	}
+	return metadata, modTime
+}
+
+// isChecksum returns true if name is a checksum file
+func isChecksum(name string) bool {
+	for _, t := range checksumTypes {
+		if strings.HasSuffix(name, artifact.ChecksumExtension(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// versionSplitRe splits a version into its parts
+var versionSplitRe = regexp.MustCompile(`[.-]`)
+
+// compareVersions compares maven versions a and b returning -1, 0 or
+// +1 if a is older, the same or newer than b
+//
+// Numeric parts are compared as numbers and other parts as strings.
+// A version with a qualifier, e.g. 1.0-beta or 1.0-SNAPSHOT, is older
+// than the version without it.
+func compareVersions(a, b string) int {
+	as, bs := versionSplitRe.Split(a, -1), versionSplitRe.Split(b, -1)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		if i >= len(as) {
+			return -compareMissing(bs[i])
+		}
+		if i >= len(bs) {
+			return compareMissing(as[i])
+		}
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+		case aErr == nil:
+			return 1
+		case bErr == nil:
+			return -1
+		default:
+			if c := strings.Compare(strings.ToLower(as[i]), strings.ToLower(bs[i])); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// compareMissing compares the extra version part part with a missing
+// one, a number makes the version newer and a qualifier older
+func compareMissing(part string) int {
+	if _, err := strconv.Atoi(part); err == nil {
+		return 1
+	}
+	return -1
+}
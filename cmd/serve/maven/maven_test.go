@@ -0,0 +1,112 @@
+package maven
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startServer starts a server serving testdata/files
+func startServer(t *testing.T) (string, func()) {
+	f, err := fs.NewFs(context.Background(), "testdata/files")
+	require.NoError(t, err)
+	router := chi.NewRouter()
+	newServer(f, "").Bind(router)
+	ts := httptest.NewServer(router)
+	return ts.URL, ts.Close
+}
+
+// get fetches path from the server returning the status and body
+func get(t *testing.T, url string) (int, string) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, resp.Body.Close())
+	}()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestGET(t *testing.T) {
+	url, tidy := startServer(t)
+	defer tidy()
+
+	for _, test := range []struct {
+		path     string
+		status   int
+		contains []string
+	}{
+		{"/org/example/app/1.0/app-1.0.jar", http.StatusOK, []string{"jar-1.0"}},
+		{"/org/example/app/1.0/app-1.0.jar.md5", http.StatusOK, []string{"f3815278945a885dd4310986a3370db3"}},
+		{"/org/example/app/1.0/app-1.0.jar.sha1", http.StatusOK, []string{"d556de1e9de98261c8e2187089e96084684d19c9"}},
+		{"/org/example/app/1.0/app-1.0.jar.sha256", http.StatusNotFound, nil},
+		{"/org/example/app/1.0/potato.jar", http.StatusNotFound, nil},
+		{"/org/example/app/1.0/potato.jar.md5", http.StatusNotFound, nil},
+		{"/org/example/app/2.0-SNAPSHOT/app-2.0-20210103.030405-2.jar.sha1", http.StatusOK, []string{"abc  app-2.0"}},
+		{"/org/example/app/maven-metadata.xml", http.StatusOK, []string{
+			"<groupId>org.example</groupId>",
+			"<artifactId>app</artifactId>",
+			"<latest>2.0-SNAPSHOT</latest>",
+			"<release>1.10</release>",
+			"<version>1.0</version>\n      <version>1.9</version>\n      <version>1.10</version>\n      <version>2.0-SNAPSHOT</version>",
+		}},
+		{"/org/example/app/2.0-SNAPSHOT/maven-metadata.xml", http.StatusOK, []string{
+			"<version>2.0-SNAPSHOT</version>",
+			"<timestamp>20210103.030405</timestamp>",
+			"<buildNumber>2</buildNumber>",
+			"<classifier>sources</classifier>\n        <extension>jar</extension>\n        <value>2.0-20210103.030405-2</value>",
+			"<extension>jar</extension>\n        <value>2.0-20210102.030405-1</value>",
+		}},
+		{"/org/example/maven-metadata.xml", http.StatusNotFound, nil},
+		{"/org/example/app/1.0/maven-metadata.xml", http.StatusNotFound, nil},
+		{"/org/example/app/", http.StatusOK, []string{"1.10/"}},
+		{"/org/potato/", http.StatusNotFound, nil},
+	} {
+		status, body := get(t, url+test.path)
+		assert.Equal(t, test.status, status, test.path)
+		for _, want := range test.contains {
+			assert.Contains(t, body, want, test.path)
+		}
+	}
+}
+
+func TestMetadataChecksum(t *testing.T) {
+	url, tidy := startServer(t)
+	defer tidy()
+
+	_, metadata := get(t, url+"/org/example/app/maven-metadata.xml")
+	status, sum := get(t, url+"/org/example/app/maven-metadata.xml.sha1")
+	assert.Equal(t, http.StatusOK, status)
+	// the checksum must match the metadata served
+	assert.Equal(t, fmt.Sprintf("%x", sha1.Sum([]byte(metadata))), sum)
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.1", -1},
+		{"1.10", "1.9", 1},
+		{"1.0", "1.0.1", -1},
+		{"1.0-beta", "1.0", -1},
+		{"1.0-SNAPSHOT", "1.0", -1},
+		{"1.0-alpha", "1.0-beta", -1},
+		{"2.0", "1.0-SNAPSHOT", 1},
+	} {
+		assert.Equal(t, test.want, compareVersions(test.a, test.b), test.a+" vs "+test.b)
+		assert.Equal(t, -test.want, compareVersions(test.b, test.a), test.b+" vs "+test.a)
+	}
+}
@@ -0,0 +1,356 @@
+// Package pypi implements a PEP 503 simple package index server for
+// rclone
+package pypi
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/walk"
+	httplib "github.com/rclone/rclone/lib/http"
+	"github.com/rclone/rclone/lib/http/auth"
+	"github.com/rclone/rclone/lib/http/serve"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/spf13/cobra"
+)
+
+// Options required for pypi server
+type Options struct {
+	IndexCacheTime time.Duration
+}
+
+// DefaultOpt is the default values used for Options
+var DefaultOpt = Options{
+	IndexCacheTime: time.Minute,
+}
+
+// Opt is options set by command line flags
+var Opt = DefaultOpt
+
+func init() {
+	flagSet := Command.Flags()
+	flags.DurationVarP(flagSet, &Opt.IndexCacheTime, "index-cache-time", "", Opt.IndexCacheTime, "Time to cache the package index for")
+	httplib.AddFlags(flagSet)
+	auth.AddFlags(flagSet)
+}
+
+// Command definition for cobra
+var Command = &cobra.Command{
+	Use:   "pypi remote:path",
+	Short: `Serve the remote as a PEP 503 simple package index.`,
+	Long: `rclone serve pypi implements a read only python package index on top
+of any remote so that pip can install packages straight from it.
+
+The remote is scanned recursively for wheels, eggs and source
+distributions and these are grouped into projects using their file
+names, so they can be stored flat or in directories in any layout.
+
+The index is served at /simple/ so use
+
+    pip install --index-url http://localhost:8080/simple/ package
+
+to install packages from it.
+
+The links to the files carry the SHA-1 or MD5 hash of the file if the
+backend supplies one so pip can check the downloads. Files are not
+read to calculate hashes, so backends which would have to do that,
+like the local disk, don't supply them.
+
+The scan of the remote is cached for --index-cache-time so new files
+can take this long to show up.
+
+You can use the filter flags (e.g. --include, --exclude) to control what
+is served.
+
+The server will log errors.  Use -v to see access logs.
+
+--bwlimit will be respected for file transfers.  Use --stats to
+control the stats printing.
+` + httplib.Help + auth.Help,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		f := cmd.NewFsSrc(args)
+		cmd.Run(false, true, command, func() error {
+			s := newServer(f, &Opt)
+			router, err := httplib.Router()
+			if err != nil {
+				return err
+			}
+			s.Bind(router)
+			return nil
+		})
+	},
+}
+
+// hashTypes are the hash types which can be put in the links in
+// order of preference
+var hashTypes = []hash.Type{hash.SHA1, hash.MD5}
+
+// extensions are the extensions of the distribution files
+var extensions = []string{".whl", ".egg", ".tar.gz", ".tgz", ".tar.bz2", ".tar.xz", ".zip"}
+
+// sdistRe splits a source distribution file name without its
+// extension into the project name and version
+var sdistRe = regexp.MustCompile(`^(.+?)-(\d.*)$`)
+
+// normalizeRe matches the runs of characters PEP 503 normalizes
+var normalizeRe = regexp.MustCompile(`[-_.]+`)
+
+// normalize returns the PEP 503 normalized form of a project name
+func normalize(name string) string {
+	return strings.ToLower(normalizeRe.ReplaceAllString(name, "-"))
+}
+
+// projectName returns the project name of the distribution file
+// name or "" if it isn't one
+func projectName(name string) string {
+	for _, ext := range extensions {
+		if !strings.HasSuffix(strings.ToLower(name), ext) {
+			continue
+		}
+		base := name[:len(name)-len(ext)]
+		switch ext {
+		case ".whl", ".egg":
+			// {name}-{version}(-{tags})*, name never contains "-"
+			if i := strings.IndexRune(base, '-'); i > 0 {
+				return base[:i]
+			}
+		default:
+			if match := sdistRe.FindStringSubmatch(base); match != nil {
+				return match[1]
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// file is a distribution file in the index
+type file struct {
+	Name string // file name
+	URL  string // URL relative to the project page
+}
+
+// index maps normalized project names to their files
+type index map[string][]file
+
+// server contains everything to run the server
+type server struct {
+	f   fs.Fs
+	opt *Options
+
+	mu      sync.Mutex // protects the below
+	index   index      // the cached index
+	expires time.Time  // when the cached index expires
+}
+
+func newServer(f fs.Fs, opt *Options) *server {
+	return &server{
+		f:   f,
+		opt: opt,
+	}
+}
+
+func (s *server) Bind(router chi.Router) {
+	router.Use(
+		middleware.SetHeader("Server", "rclone/"+fs.Version),
+	)
+	router.Get("/simple/", s.serveRoot)
+	router.Head("/simple/", s.serveRoot)
+	router.Get("/simple/{project}", s.redirectProject)
+	router.Head("/simple/{project}", s.redirectProject)
+	router.Get("/simple/{project}/", s.serveProject)
+	router.Head("/simple/{project}/", s.serveProject)
+	router.Get("/packages/*", s.serveFile)
+	router.Head("/packages/*", s.serveFile)
+}
+
+// getIndex returns the index, scanning the remote if the cached one
+// has expired
+//
+// The scan doesn't use the context of the request which triggered it
+// as the other requests waiting for it would fail if that request
+// was cancelled.
+func (s *server) getIndex() (index, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index != nil && time.Now().Before(s.expires) {
+		return s.index, nil
+	}
+	idx, err := s.scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	s.index = idx
+	s.expires = time.Now().Add(s.opt.IndexCacheTime)
+	return idx, nil
+}
+
+// scan lists the remote recursively to make the index
+func (s *server) scan(ctx context.Context) (index, error) {
+	fs.Debugf(s.f, "Scanning for packages")
+	hashType := hash.None
+	for _, t := range hashTypes {
+		if s.f.Hashes().Contains(t) && !s.f.Features().SlowHash {
+			hashType = t
+			break
+		}
+	}
+	idx := index{}
+	err := walk.ListR(ctx, s.f, "", false, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			o, ok := entry.(fs.Object)
+			if !ok {
+				continue
+			}
+			name := path.Base(o.Remote())
+			project := projectName(name)
+			if project == "" {
+				continue
+			}
+			link := "../../packages/" + rest.URLPathEscape(o.Remote())
+			if hashType != hash.None {
+				sum, err := o.Hash(ctx, hashType)
+				if err != nil {
+					fs.Debugf(o, "Failed to read hash: %v", err)
+				} else if sum != "" {
+					link += "#" + hashType.String() + "=" + sum
+				}
+			}
+			project = normalize(project)
+			idx[project] = append(idx[project], file{Name: name, URL: link})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to scan for packages")
+	}
+	for _, files := range idx {
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].Name < files[j].Name
+		})
+	}
+	fs.Debugf(s.f, "Found %d projects", len(idx))
+	return idx, nil
+}
+
+// rootTemplate is the page listing the projects
+var rootTemplate = template.Must(template.New("root").Parse(`<!DOCTYPE html>
+<html>
+  <head>
+    <title>Simple index</title>
+  </head>
+  <body>
+{{- range .}}
+    <a href="{{.}}/">{{.}}</a>
+{{- end}}
+  </body>
+</html>
+`))
+
+// projectTemplate is the page listing the files of a project
+var projectTemplate = template.Must(template.New("project").Parse(`<!DOCTYPE html>
+<html>
+  <head>
+    <title>Links for {{.Project}}</title>
+  </head>
+  <body>
+    <h1>Links for {{.Project}}</h1>
+{{- range .Files}}
+    <a href="{{.URL}}">{{.Name}}</a>
+{{- end}}
+  </body>
+</html>
+`))
+
+// serveRoot serves the list of projects
+func (s *server) serveRoot(w http.ResponseWriter, r *http.Request) {
+	idx, err := s.getIndex()
+	if err != nil {
+		serve.Error("simple", w, "Failed to read index", err)
+		return
+	}
+	projects := make([]string, 0, len(idx))
+	for project := range idx {
+		projects = append(projects, project)
+	}
+	sort.Strings(projects)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.Method == "HEAD" {
+		return
+	}
+	err = rootTemplate.Execute(w, projects)
+	if err != nil {
+		fs.Errorf(nil, "Failed to render template: %v", err)
+	}
+}
+
+// redirectProject redirects to the project page with a trailing /
+// as PEP 503 requires
+func (s *server) redirectProject(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, path.Base(r.URL.Path)+"/", http.StatusMovedPermanently)
+}
+
+// serveProject serves the list of files of a project
+func (s *server) serveProject(w http.ResponseWriter, r *http.Request) {
+	idx, err := s.getIndex()
+	if err != nil {
+		serve.Error("simple", w, "Failed to read index", err)
+		return
+	}
+	project := chi.URLParam(r, "project")
+	normalized := normalize(project)
+	if normalized != project {
+		http.Redirect(w, r, "../"+normalized+"/", http.StatusMovedPermanently)
+		return
+	}
+	files, ok := idx[project]
+	if !ok {
+		fs.Infof(project, "%s: Project not found", r.RemoteAddr)
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.Method == "HEAD" {
+		return
+	}
+	err = projectTemplate.Execute(w, struct {
+		Project string
+		Files   []file
+	}{
+		Project: project,
+		Files:   files,
+	})
+	if err != nil {
+		fs.Errorf(nil, "Failed to render template: %v", err)
+	}
+}
+
+// serveFile serves a distribution file
+func (s *server) serveFile(w http.ResponseWriter, r *http.Request) {
+	remote := strings.Trim(strings.TrimPrefix(r.URL.Path, "/packages/"), "/")
+	o, err := s.f.NewObject(r.Context(), remote)
+	if err == fs.ErrorObjectNotFound || err == fs.ErrorIsDir {
+		fs.Infof(remote, "%s: File not found", r.RemoteAddr)
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serve.Error(remote, w, "Failed to find file", err)
+		return
+	}
+	serve.Object(w, r, o)
+}
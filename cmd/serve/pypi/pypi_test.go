@@ -0,0 +1,97 @@
+package pypi
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startServer starts a server serving testdata/files
+func startServer(t *testing.T) (string, func()) {
+	f, err := fs.NewFs(context.Background(), "testdata/files")
+	require.NoError(t, err)
+	router := chi.NewRouter()
+	newServer(f, &DefaultOpt).Bind(router)
+	ts := httptest.NewServer(router)
+	return ts.URL, ts.Close
+}
+
+// get fetches url returning the status, the final URL and the body
+func get(t *testing.T, url string) (int, string, string) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, resp.Body.Close())
+	}()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, resp.Request.URL.Path, string(body)
+}
+
+func TestGET(t *testing.T) {
+	url, tidy := startServer(t)
+	defer tidy()
+
+	for _, test := range []struct {
+		path     string
+		status   int
+		final    string
+		contains []string
+	}{
+		{"/simple/", http.StatusOK, "/simple/", []string{
+			`<a href="requests/">requests</a>`,
+			`<a href="zope-interface/">zope-interface</a>`,
+		}},
+		{"/simple/requests/", http.StatusOK, "/simple/requests/", []string{
+			`<a href="../../packages/dists/requests/requests-2.25.1-py2.py3-none-any.whl">requests-2.25.1-py2.py3-none-any.whl</a>`,
+			`requests-2.25.1.tar.gz</a>`,
+		}},
+		{"/simple/requests", http.StatusOK, "/simple/requests/", nil},
+		{"/simple/Zope_Interface/", http.StatusOK, "/simple/zope-interface/", []string{
+			`Zope.Interface-5.0.tar.gz</a>`,
+		}},
+		{"/simple/potato/", http.StatusNotFound, "/simple/potato/", nil},
+		{"/packages/dists/requests/requests-2.25.1-py2.py3-none-any.whl", http.StatusOK, "", []string{"wheel"}},
+		{"/packages/dists/requests/potato.whl", http.StatusNotFound, "", nil},
+	} {
+		status, final, body := get(t, url+test.path)
+		assert.Equal(t, test.status, status, test.path)
+		if test.final != "" {
+			assert.Equal(t, test.final, final, test.path)
+		}
+		for _, want := range test.contains {
+			assert.Contains(t, body, want, test.path)
+		}
+	}
+}
+
+func TestProjectName(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		want string
+	}{
+		{"requests-2.25.1-py2.py3-none-any.whl", "requests"},
+		{"requests-2.25.1.tar.gz", "requests"},
+		{"python-dateutil-2.8.1.tar.gz", "python-dateutil"},
+		{"Zope.Interface-5.0.zip", "Zope.Interface"},
+		{"setuptools-0.6c11-py2.7.egg", "setuptools"},
+		{"README.txt", ""},
+		{"nodash.tar.gz", ""},
+	} {
+		assert.Equal(t, test.want, projectName(test.name), test.name)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "zope-interface", normalize("Zope.Interface"))
+	assert.Equal(t, "foo-bar", normalize("foo__bar"))
+	assert.Equal(t, "foo-bar", normalize("Foo-_.Bar"))
+}
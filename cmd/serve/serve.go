@@ -7,6 +7,8 @@ import (
 	"github.com/rclone/rclone/cmd/serve/dlna"
 	"github.com/rclone/rclone/cmd/serve/ftp"
 	"github.com/rclone/rclone/cmd/serve/http"
+	"github.com/rclone/rclone/cmd/serve/maven"
+	"github.com/rclone/rclone/cmd/serve/pypi"
 	"github.com/rclone/rclone/cmd/serve/restic"
 	"github.com/rclone/rclone/cmd/serve/sftp"
 	"github.com/rclone/rclone/cmd/serve/webdav"
@@ -30,6 +32,12 @@ func init() {
 	if sftp.Command != nil {
 		Command.AddCommand(sftp.Command)
 	}
+	if maven.Command != nil {
+		Command.AddCommand(maven.Command)
+	}
+	if pypi.Command != nil {
+		Command.AddCommand(pypi.Command)
+	}
 	cmd.Root.AddCommand(Command)
 }
 
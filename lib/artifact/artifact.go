@@ -0,0 +1,240 @@
+// Package artifact contains utilities shared by the backends for
+// artifact repository managers
+package artifact
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// Maven identifies a file of a maven artifact version
+type Maven struct {
+	GroupID     string // e.g. org.example
+	ArtifactID  string // e.g. app
+	Version     string // e.g. 1.0 or 1.0-SNAPSHOT
+	FileVersion string // version in the file name if different, e.g. 1.0-20210101.120000-1
+	Classifier  string // e.g. sources, may be empty
+	Extension   string // e.g. jar
+}
+
+// Dir returns the directory of the artifact version in the maven2
+// layout, e.g. org/example/app/1.0
+func (m *Maven) Dir() string {
+	return path.Join(strings.Replace(m.GroupID, ".", "/", -1), m.ArtifactID, m.Version)
+}
+
+// FileName returns the name of the file, e.g. app-1.0-sources.jar
+func (m *Maven) FileName() string {
+	version := m.FileVersion
+	if version == "" {
+		version = m.Version
+	}
+	name := m.ArtifactID + "-" + version
+	if m.Classifier != "" {
+		name += "-" + m.Classifier
+	}
+	return name + "." + m.Extension
+}
+
+// Path returns the path of the file in the maven2 layout,
+// e.g. org/example/app/1.0/app-1.0-sources.jar
+func (m *Maven) Path() string {
+	return path.Join(m.Dir(), m.FileName())
+}
+
+// checksumExtensions are the extensions of the checksum files stored
+// next to artifacts for each hash type
+var checksumExtensions = map[hash.Type]string{
+	hash.MD5:  ".md5",
+	hash.SHA1: ".sha1",
+}
+
+// ChecksumExtension returns the extension of the checksum file for
+// hash type t, or "" if there isn't one
+func ChecksumExtension(t hash.Type) string {
+	return checksumExtensions[t]
+}
+
+// Checksums are the checksums repository managers keep for each file
+// as lowercase hex strings, "" if not known
+type Checksums struct {
+	MD5  string
+	SHA1 string
+}
+
+// NewChecksums returns the Checksums for md5 and sha1 which may be in
+// either case
+func NewChecksums(md5, sha1 string) Checksums {
+	return Checksums{
+		MD5:  strings.ToLower(md5),
+		SHA1: strings.ToLower(sha1),
+	}
+}
+
+// ChecksumTypes returns the hash types held in Checksums
+func ChecksumTypes() hash.Set {
+	return hash.NewHashSet(hash.MD5, hash.SHA1)
+}
+
+// Ref returns a pointer to the checksum of type t or nil if
+// Checksums doesn't hold that type
+func (c *Checksums) Ref(t hash.Type) *string {
+	switch t {
+	case hash.MD5:
+		return &c.MD5
+	case hash.SHA1:
+		return &c.SHA1
+	}
+	return nil
+}
+
+// Get returns the checksum of type t in the form needed by
+// fs.Object.Hash
+func (c *Checksums) Get(t hash.Type) (string, error) {
+	checksum := c.Ref(t)
+	if checksum == nil {
+		return "", hash.ErrUnsupported
+	}
+	return *checksum, nil
+}
+
+// maxChecksumSize is the most read from a checksum file
+const maxChecksumSize = 1024
+
+// ReadChecksum reads a checksum file returning the lowercase checksum
+// or "" if the file is empty
+//
+// Checksum files may have the file name after the checksum in the
+// same way as the output of md5sum.
+func ReadChecksum(in io.Reader) (string, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(in, maxChecksumSize))
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// linkNextRe matches the URL of the next page in a Link header
+var linkNextRe = regexp.MustCompile(`<([^>]*)>;\s*rel="?next"?`)
+
+// NextLink returns the URL of the next page from the Link header in
+// header, or "" if there isn't one
+func NextLink(header http.Header) string {
+	for _, link := range header.Values("Link") {
+		if match := linkNextRe.FindStringSubmatch(link); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// Paginate reads all the pages of a listing which uses continuation
+// tokens
+//
+// fn is called with "" to read the first page and then with the
+// token it returned for the previous page until it returns "". It
+// returns an error if a token comes round again as the listing would
+// never end.
+func Paginate(fn func(token string) (next string, err error)) error {
+	token := ""
+	seen := map[string]struct{}{}
+	for {
+		next, err := fn(token)
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			return nil
+		}
+		if _, found := seen[next]; found {
+			return errors.Errorf("pagination token %q repeated", next)
+		}
+		seen[next] = struct{}{}
+		token = next
+	}
+}
+
+// ModTimeProperty is the property used to store the modification
+// time of files in repository managers which allow arbitrary
+// properties or tags on them
+const ModTimeProperty = "rclone.mtime"
+
+// modTimeFormat is the format of the ModTimeProperty
+const modTimeFormat = time.RFC3339Nano
+
+// Properties are the properties or tags of a file, each of which may
+// have several values
+type Properties map[string][]string
+
+// ModTime returns the modification time stored in the properties or
+// the zero time if there isn't one
+func (p Properties) ModTime() (time.Time, error) {
+	values := p[ModTimeProperty]
+	if len(values) == 0 {
+		return time.Time{}, nil
+	}
+	modTime, err := time.Parse(modTimeFormat, values[0])
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "bad %s property", ModTimeProperty)
+	}
+	return modTime, nil
+}
+
+// SetModTime stores modTime in the properties
+func (p Properties) SetModTime(modTime time.Time) {
+	p[ModTimeProperty] = []string{modTime.UTC().Format(modTimeFormat)}
+}
+
+// propertyEscaper escapes the separators in property names and values
+var propertyEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, "|", `\|`, "=", `\=`, ";", `\;`)
+
+// encode returns the properties sorted by name as name=value,value
+// joined with sep
+func (p Properties) encode(prefix, sep string) string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var out strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			out.WriteString(sep)
+		}
+		out.WriteString(prefix)
+		out.WriteString(propertyEscaper.Replace(name))
+		out.WriteString("=")
+		for j, value := range p[name] {
+			if j > 0 {
+				out.WriteString(",")
+			}
+			out.WriteString(propertyEscaper.Replace(value))
+		}
+	}
+	return out.String()
+}
+
+// Matrix returns the properties as matrix parameters to add to a
+// path, e.g. ";a=1;b=2,3"
+func (p Properties) Matrix() string {
+	return p.encode(";", "")
+}
+
+// Query returns the properties in the form used by query parameters,
+// e.g. "a=1|b=2,3"
+func (p Properties) Query() string {
+	return p.encode("", "|")
+}
@@ -0,0 +1,163 @@
+package artifact
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaven(t *testing.T) {
+	for _, test := range []struct {
+		in       Maven
+		wantDir  string
+		wantName string
+	}{
+		{
+			in:       Maven{GroupID: "org.example", ArtifactID: "app", Version: "1.0", Extension: "jar"},
+			wantDir:  "org/example/app/1.0",
+			wantName: "app-1.0.jar",
+		},
+		{
+			in:       Maven{GroupID: "com", ArtifactID: "lib", Version: "2.1", Classifier: "sources", Extension: "jar"},
+			wantDir:  "com/lib/2.1",
+			wantName: "lib-2.1-sources.jar",
+		},
+		{
+			in:       Maven{GroupID: "org.example", ArtifactID: "app", Version: "1.1-SNAPSHOT", FileVersion: "1.1-20210101.120000-1", Extension: "pom"},
+			wantDir:  "org/example/app/1.1-SNAPSHOT",
+			wantName: "app-1.1-20210101.120000-1.pom",
+		},
+	} {
+		assert.Equal(t, test.wantDir, test.in.Dir())
+		assert.Equal(t, test.wantName, test.in.FileName())
+		assert.Equal(t, test.wantDir+"/"+test.wantName, test.in.Path())
+	}
+}
+
+func TestChecksumExtension(t *testing.T) {
+	assert.Equal(t, ".md5", ChecksumExtension(hash.MD5))
+	assert.Equal(t, ".sha1", ChecksumExtension(hash.SHA1))
+	assert.Equal(t, "", ChecksumExtension(hash.CRC32))
+}
+
+func TestChecksums(t *testing.T) {
+	c := NewChecksums("5D41402ABC4B2A76B9719D911017C592", "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d")
+	md5, err := c.Get(hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", md5)
+	sha1, err := c.Get(hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", sha1)
+	_, err = c.Get(hash.CRC32)
+	assert.Equal(t, hash.ErrUnsupported, err)
+
+	*c.Ref(hash.MD5) = ""
+	assert.Equal(t, "", c.MD5)
+	assert.Nil(t, c.Ref(hash.CRC32))
+
+	assert.True(t, ChecksumTypes().Contains(hash.MD5))
+	assert.True(t, ChecksumTypes().Contains(hash.SHA1))
+	assert.Equal(t, 2, ChecksumTypes().Count())
+}
+
+func TestReadChecksum(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"\n", ""},
+		{"A9993E364706816ABA3E25717850C26C9CD0D89D", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"a9993e364706816aba3e25717850c26c9cd0d89d  app-1.0.jar\n", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+	} {
+		got, err := ReadChecksum(strings.NewReader(test.in))
+		require.NoError(t, err)
+		assert.Equal(t, test.want, got, test.in)
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	for _, test := range []struct {
+		in   []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{`<https://api.example.com/packages?page=2>; rel="next", <https://api.example.com/packages?page=5>; rel="last"`}, "https://api.example.com/packages?page=2"},
+		{[]string{`<https://api.example.com/packages?page=1>; rel="prev", <https://api.example.com/packages?page=1>; rel="first"`}, ""},
+		{[]string{`<https://api.example.com/packages?page=1>; rel="prev"`, `<https://api.example.com/packages?page=3>; rel=next`}, "https://api.example.com/packages?page=3"},
+	} {
+		header := http.Header{}
+		for _, link := range test.in {
+			header.Add("Link", link)
+		}
+		assert.Equal(t, test.want, NextLink(header), strings.Join(test.in, " | "))
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	pages := map[string]string{"": "a", "a": "b", "b": ""}
+	var tokens []string
+	err := Paginate(func(token string) (string, error) {
+		tokens = append(tokens, token)
+		return pages[token], nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "a", "b"}, tokens)
+
+	// errors stop the pagination
+	errPage := errors.New("bad page")
+	tokens = nil
+	err = Paginate(func(token string) (string, error) {
+		tokens = append(tokens, token)
+		if token == "a" {
+			return "", errPage
+		}
+		return pages[token], nil
+	})
+	assert.Equal(t, errPage, err)
+	assert.Equal(t, []string{"", "a"}, tokens)
+
+	// a repeated token would loop forever
+	err = Paginate(func(token string) (string, error) {
+		return "a", nil
+	})
+	assert.Error(t, err)
+
+	// as would a cycle of tokens
+	cycle := map[string]string{"": "a", "a": "b", "b": "a"}
+	tokens = nil
+	err = Paginate(func(token string) (string, error) {
+		tokens = append(tokens, token)
+		return cycle[token], nil
+	})
+	assert.EqualError(t, err, `pagination token "a" repeated`)
+	assert.Equal(t, []string{"", "a", "b"}, tokens)
+}
+
+func TestProperties(t *testing.T) {
+	p := Properties{}
+	modTime, err := p.ModTime()
+	require.NoError(t, err)
+	assert.True(t, modTime.IsZero())
+
+	want := time.Date(2021, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	p.SetModTime(want)
+	assert.Equal(t, []string{"2021-01-02T03:04:05.6Z"}, p[ModTimeProperty])
+	modTime, err = p.ModTime()
+	require.NoError(t, err)
+	assert.Equal(t, want, modTime)
+
+	p[ModTimeProperty] = []string{"potato"}
+	_, err = p.ModTime()
+	assert.Error(t, err)
+
+	p = Properties{"b": {"2", "3"}, "a": {"1"}, "c": {"x=y;z|w,v"}}
+	assert.Equal(t, `;a=1;b=2,3;c=x\=y\;z\|w\,v`, p.Matrix())
+	assert.Equal(t, `a=1|b=2,3|c=x\=y\;z\|w\,v`, p.Query())
+}